@@ -4,14 +4,26 @@ import (
 	"database/sql"
 	"encoding/json"
 	"reflect"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 type Adapter struct {
-	db     *sql.DB
-	logger iLogger
-	isLog  bool
+	db            *sql.DB
+	logger        iLogger
+	isLog         bool
+	schemas       *schemaCache
+	codecs        *codecRegistry
+	loc           *time.Location
+	stringResults bool
+	strictDecimal bool
+	autoinc       *autoincMode
+	clock         func() time.Time
+	scopes        *scopeRegistry
+	tenant        *tenant
+	database      string
+	allowRaw      bool
 }
 
 func (adapter *Adapter) Debug(flag ...bool) {
@@ -30,6 +42,25 @@ func (adapter *Adapter) SetLogger(logger iLogger) {
 	adapter.logger = logger
 }
 
+// SetStringResults makes Fetch, FetchAll and Query return text and binary
+// columns as string and leave other values as the driver returned them,
+// instead of converting every column to a Go type matching its SQL type.
+func (adapter *Adapter) SetStringResults(flag bool) {
+	adapter.stringResults = flag
+}
+
+// SetStrictDecimal refuses to scan DECIMAL columns into float fields and to
+// write float fields tagged db:",decimal".
+func (adapter *Adapter) SetStrictDecimal(flag bool) {
+	adapter.strictDecimal = flag
+}
+
+// SetNamingStrategy changes the table and column names derived from structs,
+// for the adapter and the handles derived from it.
+func (adapter *Adapter) SetNamingStrategy(naming NamingStrategy) {
+	adapter.schemas.reset(naming)
+}
+
 func (adapter *Adapter) NewModel() *Model {
 	entity := &Model{adapter: adapter}
 	entity.Init()
@@ -42,6 +73,12 @@ func (adapter *Adapter) Table(args string) *Model {
 	return entity.Table(args)
 }
 
+func (adapter *Adapter) Model(entity interface{}) *Model {
+	model := adapter.NewModel()
+	model.isAutoCommit = true
+	return model.Model(entity)
+}
+
 func (adapter *Adapter) Id(args interface{}) *Model {
 	entity := adapter.NewModel()
 	entity.isAutoCommit = true
@@ -122,6 +159,13 @@ func (adapter *Adapter) Scan(sour interface{}, dest interface{}) error {
 		return errors.New("First parameter is a map slice, second parameter must be a struct pointer slice.")
 	}
 
+	switch src := sour.(type) {
+	case map[string]interface{}:
+		return map2struct(adapter, dest, src)
+	case []map[string]interface{}:
+		return slice2struct(adapter, dest, src)
+	}
+
 	b, err := json.Marshal(sour)
 	if err != nil {
 		return err
@@ -133,10 +177,14 @@ func (adapter *Adapter) DB() *sql.DB {
 	return adapter.db
 }
 
+// Close closes the connection pool, which adapters derived with ForTenant
+// and Database share.
 func (adapter *Adapter) Close() error {
 	return adapter.db.Close()
 }
 
+// SetMaxIdleConns and SetMaxOpenConns configure the connection pool shared
+// with derived adapters.
 func (adapter *Adapter) SetMaxIdleConns(n int) {
 	adapter.db.SetMaxIdleConns(n)
 }
@@ -0,0 +1,409 @@
+package mysqldb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Association writes the related rows of an entity bound with Model. Each
+// call runs in the transaction of the model, or in its own one.
+type Association struct {
+	model    *Model
+	owner    reflect.Value
+	schema   *schema
+	relation *relation
+	err      error
+}
+
+// Association returns the named relation of the entity bound with Model.
+func (model *Model) Association(name string) *Association {
+	a := &Association{model: model, owner: model.statement.entity, schema: model.statement.schema}
+	if !a.owner.IsValid() || a.schema == nil {
+		a.err = errors.New(ASSOCIATION_ERROR)
+		return a
+	}
+	r, ok := a.schema.relation(name)
+	if !ok {
+		a.err = fmt.Errorf("%s: %s", name, RELATION_ERROR)
+		return a
+	}
+	a.relation, a.err = r.keys(model.adapter.schemas, a.schema)
+	return a
+}
+
+// Append links values, pointers to related structs or slices of them, to
+// the entity. New related rows are inserted first. For belongs_to and
+// has_one relations the last value replaces the current one.
+func (a *Association) Append(values ...interface{}) error {
+	items, err := a.items(values)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+	if !a.relation.many() {
+		return a.Replace(items[len(items)-1].Interface())
+	}
+
+	err = a.run(func(m *Model) error {
+		return a.link(m, items)
+	})
+	if err != nil {
+		return err
+	}
+	a.set(append(a.current(), items...))
+	return nil
+}
+
+// Replace makes values the only related rows of the entity. Rows no longer
+// related are unlinked, not deleted.
+func (a *Association) Replace(values ...interface{}) error {
+	items, err := a.items(values)
+	if err != nil {
+		return err
+	}
+	if !a.relation.many() && len(items) > 1 {
+		items = items[len(items)-1:]
+	}
+
+	err = a.run(func(m *Model) error {
+		if err := a.unlink(m, items, true); err != nil {
+			return err
+		}
+		return a.link(m, items)
+	})
+	if err != nil {
+		return err
+	}
+	a.set(items)
+	return nil
+}
+
+// Delete unlinks values from the entity. The related rows themselves are
+// kept.
+func (a *Association) Delete(values ...interface{}) error {
+	items, err := a.items(values)
+	if err != nil || len(items) == 0 {
+		return err
+	}
+
+	err = a.run(func(m *Model) error {
+		return a.unlink(m, items, false)
+	})
+	if err != nil {
+		return err
+	}
+	removed := make(map[string]bool, len(items))
+	for _, item := range items {
+		removed[keyString(a.itemKey(item))] = true
+	}
+	kept := make([]reflect.Value, 0)
+	for _, item := range a.current() {
+		if !removed[keyString(a.itemKey(item))] {
+			kept = append(kept, item)
+		}
+	}
+	a.set(kept)
+	return nil
+}
+
+// Clear unlinks every related row from the entity.
+func (a *Association) Clear() error {
+	return a.Replace()
+}
+
+// Count returns the number of rows related to the entity.
+func (a *Association) Count() (int64, error) {
+	if a.err != nil {
+		return 0, a.err
+	}
+	r := a.relation
+	key, ok := a.ownerKey()
+	if !ok {
+		return 0, nil
+	}
+	m := a.model.session()
+	if r.kind == manyToMany {
+		return m.Table(r.pivot).Where(r.joinForeignKey, key).Count()
+	}
+	return m.Model(reflect.New(r.elem).Interface()).Where(r.relatedKey(), key).Count()
+}
+
+func (a *Association) run(fn func(m *Model) error) error {
+	m := a.model.session()
+	return m.transaction(func() error {
+		return fn(m)
+	})
+}
+
+// link inserts the new items and points them, or the entity for
+// belongs_to, at each other.
+func (a *Association) link(m *Model, items []reflect.Value) error {
+	r := a.relation
+	if len(items) == 0 {
+		return nil
+	}
+
+	switch r.kind {
+	case belongsTo:
+		if err := a.save(m, items[0]); err != nil {
+			return err
+		}
+		return a.setOwnerKey(m, a.itemKey(items[0]))
+	case hasOne, hasMany:
+		key, ok := a.ownerKey()
+		if !ok {
+			return errors.New(KEY_ZERO_ERROR)
+		}
+		fk, ok := a.related().field(r.foreignKey)
+		if !ok {
+			return fmt.Errorf("%s: unknown column %s", r.name, r.foreignKey)
+		}
+		for _, item := range items {
+			if err := m.adapter.assignField(fk, fk.settable(item.Elem()), key); err != nil {
+				return err
+			}
+			pk, err := a.itemPk(item)
+			if err != nil {
+				if err := a.save(m, item); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := m.Model(item.Interface()).Id(pk).Update(map[string]interface{}{r.foreignKey: key}); err != nil {
+				return err
+			}
+		}
+	case manyToMany:
+		key, ok := a.ownerKey()
+		if !ok {
+			return errors.New(KEY_ZERO_ERROR)
+		}
+		keys := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			if err := a.save(m, item); err != nil {
+				return err
+			}
+			keys = append(keys, a.itemKey(item))
+		}
+		existing, err := m.Table(r.pivot).Fields(r.joinReferences).Where(r.joinForeignKey, key).WhereIn(r.joinReferences, keys).FetchAll()
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(existing))
+		for _, row := range existing {
+			seen[keyString(row[r.joinReferences])] = true
+		}
+		rows := make([]map[string]interface{}, 0, len(keys))
+		for _, k := range keys {
+			if !seen[keyString(k)] {
+				seen[keyString(k)] = true
+				rows = append(rows, map[string]interface{}{r.joinForeignKey: key, r.joinReferences: k})
+			}
+		}
+		if len(rows) > 0 {
+			if _, err := m.Table(r.pivot).MultiInsert(rows); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unlink detaches the related rows in items or, when except is set, all
+// related rows but those in items.
+func (a *Association) unlink(m *Model, items []reflect.Value, except bool) error {
+	r := a.relation
+	if r.kind == belongsTo {
+		if except && len(items) > 0 {
+			return nil
+		}
+		return a.setOwnerKey(m, nil)
+	}
+
+	key, ok := a.ownerKey()
+	if !ok {
+		return nil
+	}
+	if r.kind == manyToMany {
+		keys := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			if k := a.itemKey(item); !empty(k) {
+				keys = append(keys, k)
+			}
+		}
+		q := m.Table(r.pivot).Where(r.joinForeignKey, key)
+		if except && len(keys) > 0 {
+			q.WhereNotIn(r.joinReferences, keys)
+		} else if !except {
+			if len(keys) == 0 {
+				return nil
+			}
+			q.WhereIn(r.joinReferences, keys)
+		}
+		_, err := q.Delete()
+		return err
+	}
+
+	pks := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		if pk, err := a.itemPk(item); err == nil {
+			pks = append(pks, pk)
+		}
+	}
+	related := a.related()
+	q := m.Model(reflect.New(r.elem).Interface()).Where(r.foreignKey, key)
+	if except && len(pks) > 0 {
+		q.WhereNotIn(related.pk[0].column, pks)
+	} else if !except {
+		if len(pks) == 0 {
+			return nil
+		}
+		q.WhereIn(related.pk[0].column, pks)
+	}
+	_, err := q.Update(map[string]interface{}{r.foreignKey: nil})
+	return err
+}
+
+// save inserts item when its primary key is zero.
+func (a *Association) save(m *Model, item reflect.Value) error {
+	if _, ok := m.Model(item.Interface()).keyValue(item.Elem()); ok {
+		m.reset()
+		return nil
+	}
+	m.reset()
+	_, err := m.Insert(item.Interface())
+	return err
+}
+
+// setOwnerKey writes the foreign key of a belongs_to relation to the entity
+// and its row.
+func (a *Association) setOwnerKey(m *Model, key interface{}) error {
+	f, ok := a.schema.field(a.relation.foreignKey)
+	if !ok {
+		return fmt.Errorf("%s: unknown column %s", a.relation.name, a.relation.foreignKey)
+	}
+	pk, ok := m.Model(a.owner.Addr().Interface()).keyValue(a.owner)
+	if !ok {
+		m.reset()
+		return errors.New(KEY_ZERO_ERROR)
+	}
+	if _, err := m.Id(pk).Update(map[string]interface{}{f.column: key}); err != nil {
+		return err
+	}
+	return m.adapter.assignField(f, f.settable(a.owner), key)
+}
+
+func (a *Association) related() *schema {
+	return a.model.adapter.schemas.schemaOf(a.relation.elem)
+}
+
+// ownerKey returns the value of the entity matched by related rows.
+func (a *Association) ownerKey() (interface{}, bool) {
+	f, ok := a.schema.field(a.relation.ownerKey())
+	if !ok {
+		return nil, false
+	}
+	fv, ok := f.value(a.owner)
+	if !ok || fv.IsZero() {
+		return nil, false
+	}
+	return reflect.Indirect(fv).Interface(), true
+}
+
+// itemKey returns the value of a related struct matched against the entity.
+func (a *Association) itemKey(item reflect.Value) interface{} {
+	f, ok := a.related().field(a.relation.relatedKey())
+	if a.relation.kind == hasOne || a.relation.kind == hasMany {
+		if len(a.related().pk) == 1 {
+			f, ok = a.related().pk[0], true
+		}
+	}
+	if !ok {
+		return nil
+	}
+	fv, ok := f.value(item.Elem())
+	if !ok {
+		return nil
+	}
+	return reflect.Indirect(fv).Interface()
+}
+
+func (a *Association) itemPk(item reflect.Value) (interface{}, error) {
+	related := a.related()
+	if len(related.pk) != 1 {
+		return nil, fmt.Errorf("%s: %s", a.relation.name, RELATION_ERROR)
+	}
+	fv, ok := related.pk[0].value(item.Elem())
+	if !ok || fv.IsZero() {
+		return nil, errors.New(KEY_ZERO_ERROR)
+	}
+	return fv.Interface(), nil
+}
+
+// items converts values to pointers to related structs.
+func (a *Association) items(values []interface{}) ([]reflect.Value, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	items := make([]reflect.Value, 0, len(values))
+	var add func(v reflect.Value) error
+	add = func(v reflect.Value) error {
+		switch {
+		case v.Kind() == reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				if err := add(v.Index(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		case v.Kind() == reflect.Ptr && !v.IsNil() && v.Type().Elem() == a.relation.elem:
+			items = append(items, v)
+			return nil
+		case v.Kind() == reflect.Struct && v.Type() == a.relation.elem:
+			if v.CanAddr() {
+				items = append(items, v.Addr())
+			} else {
+				p := reflect.New(v.Type())
+				p.Elem().Set(v)
+				items = append(items, p)
+			}
+			return nil
+		}
+		return errors.New(PARAMETER_ERROR)
+	}
+	for _, value := range values {
+		if err := add(reflect.ValueOf(value)); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// current returns the related structs held by the entity field.
+func (a *Association) current() []reflect.Value {
+	fv, ok := a.relation.field.value(a.owner)
+	if !ok {
+		return nil
+	}
+	items := make([]reflect.Value, 0)
+	if fv.Kind() == reflect.Slice {
+		for i := 0; i < fv.Len(); i++ {
+			if item := fv.Index(i); item.Kind() == reflect.Ptr {
+				items = append(items, item)
+			} else {
+				items = append(items, item.Addr())
+			}
+		}
+	} else if fv.Kind() == reflect.Ptr {
+		if !fv.IsNil() {
+			items = append(items, fv)
+		}
+	} else {
+		items = append(items, fv.Addr())
+	}
+	return items
+}
+
+func (a *Association) set(items []reflect.Value) {
+	a.relation.assign(a.owner, items)
+}
@@ -0,0 +1,111 @@
+package mysqldb
+
+import (
+	"reflect"
+	"sync"
+)
+
+// autoincMode caches whether the server hands out consecutive ids to a
+// multi-row INSERT, and the step between them.
+type autoincMode struct {
+	once sync.Once
+	step int64
+}
+
+// autoincStep returns the id step of a multi-row INSERT, or 0 when ids are
+// not guaranteed to be consecutive (innodb_autoinc_lock_mode = 2).
+func (adapter *Adapter) autoincStep() int64 {
+	if adapter.autoinc == nil {
+		return 0
+	}
+	adapter.autoinc.once.Do(func() {
+		var mode, step int64
+		err := adapter.db.QueryRow("SELECT @@innodb_autoinc_lock_mode, @@auto_increment_increment").Scan(&mode, &step)
+		if err != nil {
+			adapter.logger.Errorf("autoincStep method: %s", err.Error())
+			return
+		}
+		if mode < 2 && step > 0 {
+			adapter.autoinc.step = step
+		}
+	})
+	return adapter.autoinc.step
+}
+
+// autoIncr returns the field filled from LastInsertId: the field tagged
+// autoincr, else a single integer primary key.
+func (s *schema) autoIncr() *field {
+	for _, f := range s.fields {
+		if f.has(flagAutoIncr) && f.prefix == "" && isInteger(f.typ) {
+			return f
+		}
+	}
+	if len(s.pk) == 1 && isInteger(s.pk[0].typ) {
+		return s.pk[0]
+	}
+	return nil
+}
+
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func integerOf(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	}
+	return int64(v.Uint())
+}
+
+func setInteger(v reflect.Value, i int64) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(i)
+	default:
+		v.SetUint(uint64(i))
+	}
+}
+
+// setInsertId writes id into the auto-increment field of the struct v
+// unless the caller already set it.
+func (adapter *Adapter) setInsertId(v reflect.Value, id int64) {
+	f := adapter.schemas.schemaOf(v.Type()).autoIncr()
+	if f == nil || id == 0 {
+		return
+	}
+	if fv := f.settable(v); fv.IsZero() {
+		setInteger(fv, id)
+	}
+}
+
+// setInsertIds fills the auto-increment fields of the structs in list after
+// a multi-row INSERT whose first generated id is first. It only does so when
+// every field was zero, so all ids came from the server, and the server
+// guarantees consecutive ids.
+func (adapter *Adapter) setInsertIds(list reflect.Value, first int64) {
+	if first == 0 || list.Len() == 0 {
+		return
+	}
+	f := adapter.schemas.schemaOf(list.Type().Elem()).autoIncr()
+	if f == nil {
+		return
+	}
+	for i := 0; i < list.Len(); i++ {
+		if fv, ok := f.value(list.Index(i).Elem()); ok && !fv.IsZero() {
+			return
+		}
+	}
+	step := adapter.autoincStep()
+	if step == 0 {
+		return
+	}
+	for i := 0; i < list.Len(); i++ {
+		setInteger(f.settable(list.Index(i).Elem()), first+int64(i)*step)
+	}
+}
@@ -0,0 +1,105 @@
+package mysqldb
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// EncodeFunc converts a value of a registered type to a value the driver
+// can bind, such as []byte, string, int64 or time.Time.
+type EncodeFunc func(v interface{}) (interface{}, error)
+
+// DecodeFunc converts a column value returned by the driver to a value of
+// the registered type.
+type DecodeFunc func(src interface{}) (interface{}, error)
+
+type codec struct {
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+type codecRegistry struct {
+	mu     sync.RWMutex
+	codecs map[reflect.Type]*codec
+}
+
+func newCodecRegistry() *codecRegistry {
+	return &codecRegistry{codecs: make(map[reflect.Type]*codec)}
+}
+
+func (r *codecRegistry) register(t reflect.Type, c *codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codecs[t] = c
+}
+
+func (r *codecRegistry) lookup(t reflect.Type) *codec {
+	if r == nil || t == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.codecs[t]
+}
+
+// RegisterCodec makes values of the type of sample round-trip through
+// encode and decode in insert and update builders, where arguments and row
+// scanning. Either function may be nil when only one direction is needed.
+// The codec also applies to the handles derived with ForTenant and
+// Database, before or after they were created.
+func (adapter *Adapter) RegisterCodec(sample interface{}, encode EncodeFunc, decode DecodeFunc) {
+	t, ok := sample.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(sample)
+	}
+	adapter.codecs.register(t, &codec{encode: encode, decode: decode})
+	adapter.schemas.reset(nil)
+}
+
+// encodeArg applies a registered codec to a value about to be bound. It
+// reports false when no codec applies.
+func (adapter *Adapter) encodeArg(v interface{}) (interface{}, bool, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return nil, false, nil
+	}
+	c := adapter.codecs.lookup(rv.Type())
+	if c == nil && rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		if c = adapter.codecs.lookup(rv.Type().Elem()); c != nil {
+			v = rv.Elem().Interface()
+		}
+	}
+	if c == nil || c.encode == nil {
+		return nil, false, nil
+	}
+	val, err := c.encode(v)
+	return val, true, err
+}
+
+// decodeArg applies a registered codec for the type of dst to src. It
+// reports false when no codec applies.
+func (adapter *Adapter) decodeArg(dst reflect.Value, src interface{}) (bool, error) {
+	c := adapter.codecs.lookup(dst.Type())
+	if c == nil || c.decode == nil {
+		return false, nil
+	}
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return true, nil
+	}
+	val, err := c.decode(src)
+	if err != nil {
+		return true, err
+	}
+	if val == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return true, nil
+	}
+	rv := reflect.ValueOf(val)
+	if !rv.Type().AssignableTo(dst.Type()) {
+		return true, fmt.Errorf("codec for %s decoded a %s", dst.Type(), rv.Type())
+	}
+	dst.Set(rv)
+	return true, nil
+}
@@ -0,0 +1,71 @@
+package mysqldb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type codecMoney struct {
+	Cents int64
+}
+
+type codecOrder struct {
+	Id    int64      `db:"id,pk"`
+	Total codecMoney `db:"total"`
+}
+
+func TestRegisterCodecReachesDerivedAdapters(t *testing.T) {
+	adapter, server := newTestAdapter()
+	tenant := adapter.ForTenant(7)
+
+	// Parse the schema through the derived adapter before the codec exists.
+	tenant.schemas.schemaOf(reflect.TypeOf(codecOrder{}))
+
+	adapter.RegisterCodec(codecMoney{}, func(v interface{}) (interface{}, error) {
+		return v.(codecMoney).Cents, nil
+	}, nil)
+
+	if _, err := tenant.NewModel().Insert(&codecOrder{Id: 1, Total: codecMoney{Cents: 250}}); err != nil {
+		t.Fatal(err)
+	}
+	calls := server.sqls()
+	if len(calls) != 1 {
+		t.Fatalf("got %d statements", len(calls))
+	}
+	want := "INSERT INTO `codec_order` (`id`,`total`,`tenant_id`) VALUES (?,?,?)"
+	if calls[0].sql != want {
+		t.Fatalf("got %s", calls[0].sql)
+	}
+	if calls[0].args[1] != int64(250) {
+		t.Fatalf("got args %v", calls[0].args)
+	}
+}
+
+func TestCodecEncodeErrorInWhere(t *testing.T) {
+	adapter, server := newTestAdapter()
+	adapter.RegisterCodec(codecMoney{}, func(v interface{}) (interface{}, error) {
+		return nil, errors.New("negative amount")
+	}, nil)
+	bad := codecMoney{Cents: -1}
+
+	if _, err := adapter.Table("codec_order").Where("total", bad).FetchAll(); err == nil {
+		t.Fatal("FetchAll: expected an error")
+	}
+	if _, err := adapter.Table("codec_order").Where("total", bad).Count(); err == nil {
+		t.Fatal("Count: expected an error")
+	}
+	var order codecOrder
+	if err := adapter.NewModel().Where("total", bad).First(&order); err == nil {
+		t.Fatal("First: expected an error")
+	}
+	if _, err := adapter.Table("codec_order").Where("total", bad).Update(map[string]interface{}{"id": 2}); err == nil {
+		t.Fatal("Update: expected an error")
+	}
+	if _, err := adapter.Table("codec_order").Where("total", bad).Delete(); err == nil {
+		t.Fatal("Delete: expected an error")
+	}
+	if calls := server.sqls(); len(calls) != 0 {
+		t.Fatalf("unexpected statements: %v", calls)
+	}
+}
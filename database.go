@@ -0,0 +1,66 @@
+package mysqldb
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var identifierPattern = regexp.MustCompile("^[0-9A-Za-z_$]{1,64}$")
+
+// Database returns an adapter sharing the connection pool of adapter whose
+// queries address tables in database name, by qualifying table names
+// rather than switching the connection with USE. The name keeps its case
+// and must be a plain identifier. Raw Query and Exec, which would run in
+// the default database, are refused unless AllowRaw is called.
+// Close and the pool settings of the returned adapter act on the shared
+// pool.
+func (adapter *Adapter) Database(name string) (*Adapter, error) {
+	name = strings.TrimSpace(name)
+	if !identifierPattern.MatchString(name) {
+		return nil, errors.New(DATABASE_ERROR)
+	}
+	scoped := *adapter
+	scoped.database = name
+	scoped.allowRaw = false
+	return &scoped, nil
+}
+
+// quoteTable quotes the table name in table, which may be followed by an
+// alias, and qualifies it with the database of the adapter. Names that are
+// not plain identifiers, such as db.table or subqueries, are left as given.
+func (statement *Statement) quoteTable(table string) string {
+	table = strings.TrimSpace(table)
+	name, rest := table, ""
+	if i := strings.IndexAny(table, " \t\n"); i >= 0 {
+		name, rest = table[:i], table[i:]
+	}
+	if !identifierPattern.MatchString(name) {
+		return table
+	}
+	if statement.adapter != nil && statement.adapter.database != "" {
+		return "`" + statement.adapter.database + "`.`" + name + "`" + rest
+	}
+	return "`" + name + "`" + rest
+}
+
+func (statement *Statement) table() string {
+	return statement.quoteTable(statement.TableName)
+}
+
+// lowerUnquoted lowercases sql outside backquoted identifiers, which keep
+// their case as database and table names are case sensitive on most
+// servers.
+func lowerUnquoted(sql string) string {
+	b := []byte(sql)
+	quoted := false
+	for i, c := range b {
+		switch {
+		case c == '`':
+			quoted = !quoted
+		case !quoted && 'A' <= c && c <= 'Z':
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
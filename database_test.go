@@ -0,0 +1,94 @@
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestDatabaseKeepsCase(t *testing.T) {
+	adapter, server := newTestAdapter()
+	db, err := adapter.Database("Tenant_A")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.push(testResult{columns: []string{"id"}})
+	if _, err := db.Table("Users").Where("ID", 1).FetchAll(); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "select * from `Tenant_A`.`Users` where id = ?", int64(1))
+
+	server.push(testResult{columns: []string{"aggregate"}, rows: [][]driver.Value{{int64(0)}}})
+	if _, err := db.Table("Users").Count(); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "select count(*) as aggregate from `Tenant_A`.`Users`")
+
+	server.push(testResult{columns: []string{"id"}})
+	if _, err := db.Table("Users").LeftJoin("Roles", "A.role_id = B.id").FetchAll(); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "select * from `Tenant_A`.`Users` as a left join `Tenant_A`.`Roles` as b on a.role_id = b.id")
+
+	server.push(testResult{lastInsertId: 1, rowsAffected: 1})
+	if _, err := db.Table("Users").Insert(map[string]interface{}{"name": "x"}); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "INSERT INTO `Tenant_A`.`Users` (`name`) VALUES (?)", "x")
+
+	server.push(testResult{rowsAffected: 1})
+	if _, err := db.Table("Users").Where("id", 1).Update(map[string]interface{}{"name": "y"}); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "UPDATE `Tenant_A`.`Users` SET name = ? WHERE id = ?", "y", int64(1))
+
+	server.push(testResult{rowsAffected: 1})
+	if _, err := db.Table("Users").Where("id", 1).Delete(); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "DELETE FROM `Tenant_A`.`Users` WHERE id = ?", int64(1))
+}
+
+func TestDatabaseRejectsInvalidName(t *testing.T) {
+	adapter, _ := newTestAdapter()
+	for _, name := range []string{"x`; DROP TABLE users", "", "a.b"} {
+		if db, err := adapter.Database(name); err == nil || err.Error() != DATABASE_ERROR || db != nil {
+			t.Fatalf("%q: got %v, %v", name, db, err)
+		}
+	}
+}
+
+func TestDatabaseRefusesRawSQL(t *testing.T) {
+	adapter, server := newTestAdapter()
+	db, err := adapter.Database("tenant_a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Query("select * from users"); err == nil || err.Error() != RAW_SQL_ERROR {
+		t.Fatalf("Query: got %v", err)
+	}
+	if _, err := db.Exec("delete from users"); err == nil || err.Error() != RAW_SQL_ERROR {
+		t.Fatalf("Exec: got %v", err)
+	}
+	if _, err := db.NewModel().Query("select * from users"); err == nil || err.Error() != RAW_SQL_ERROR {
+		t.Fatalf("Model.Query: got %v", err)
+	}
+	if _, err := QueryRaw[map[string]interface{}](db.NewModel(), "select * from users"); err == nil || err.Error() != RAW_SQL_ERROR {
+		t.Fatalf("QueryRaw: got %v", err)
+	}
+	if calls := server.sqls(); len(calls) != 0 {
+		t.Fatalf("unexpected statements: %v", calls)
+	}
+
+	server.push(testResult{rowsAffected: 1})
+	if _, err := db.AllowRaw().Exec("delete from `tenant_a`.users"); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "delete from `tenant_a`.users")
+
+	// A tenant handle derived from an allowed one refuses raw SQL again.
+	if _, err := db.AllowRaw().ForTenant(7).Exec("delete from users"); err == nil || err.Error() != RAW_SQL_ERROR {
+		t.Fatalf("ForTenant: got %v", err)
+	}
+}
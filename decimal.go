@@ -0,0 +1,147 @@
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number kept in its text form. It scans from
+// and binds to DECIMAL columns without passing through float64.
+type Decimal struct {
+	value string
+}
+
+func NewDecimal(s string) (Decimal, error) {
+	v, ok := normalizeDecimal(s)
+	if !ok {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal{value: v}, nil
+}
+
+func MustDecimal(s string) Decimal {
+	d, err := NewDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func DecimalFromInt(i int64) Decimal {
+	return Decimal{value: strconv.FormatInt(i, 10)}
+}
+
+func (d Decimal) String() string {
+	if d.value == "" {
+		return "0"
+	}
+	return d.value
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Rat().Sign() == 0
+}
+
+func (d Decimal) Rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(d.String())
+	return r
+}
+
+func (d Decimal) Cmp(other Decimal) int {
+	return d.Rat().Cmp(other.Rat())
+}
+
+// Float64 returns the nearest float64 and whether it is exact.
+func (d Decimal) Float64() (float64, bool) {
+	return d.Rat().Float64()
+}
+
+func (d *Decimal) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+		return nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		*d = DecimalFromInt(v)
+		return nil
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("cannot scan %T into Decimal", src)
+	}
+
+	dec, err := NewDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = dec
+	return nil
+}
+
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	dec, err := NewDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = dec
+	return nil
+}
+
+func normalizeDecimal(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		if s[0] == '-' {
+			sign = "-"
+		}
+		s = s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return "", false
+	}
+	for _, part := range []string{intPart, fracPart} {
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return "", false
+			}
+		}
+	}
+
+	intPart = strings.TrimLeft(intPart, "0")
+	if intPart == "" {
+		intPart = "0"
+	}
+	if strings.Trim(intPart+fracPart, "0") == "" {
+		sign = ""
+	}
+	if fracPart != "" {
+		return sign + intPart + "." + fracPart, true
+	}
+	return sign + intPart, true
+}
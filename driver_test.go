@@ -0,0 +1,161 @@
+package mysqldb
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"time"
+)
+
+// testResult is the answer of testServer to one statement: rows for a
+// query, or an insert id and affected row count for an exec.
+type testResult struct {
+	columns      []string
+	types        []string
+	rows         [][]driver.Value
+	lastInsertId int64
+	rowsAffected int64
+}
+
+type testCall struct {
+	sql  string
+	args []driver.Value
+}
+
+// testServer is an in-memory database/sql driver recording the statements
+// it receives and answering them from a queue, then from fallback.
+type testServer struct {
+	mu       sync.Mutex
+	calls    []testCall
+	queue    []testResult
+	fallback testResult
+	open     int
+}
+
+func (s *testServer) push(results ...testResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = append(s.queue, results...)
+}
+
+func (s *testServer) next(query string, args []driver.Value) testResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, testCall{sql: query, args: args})
+	if len(s.queue) == 0 {
+		return s.fallback
+	}
+	r := s.queue[0]
+	s.queue = s.queue[1:]
+	return r
+}
+
+// openRows returns the number of result sets not closed yet.
+func (s *testServer) openRows() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.open
+}
+
+// sqls returns the statements received since the last call.
+func (s *testServer) sqls() []testCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := s.calls
+	s.calls = nil
+	return calls
+}
+
+func (s *testServer) Connect(context.Context) (driver.Conn, error) { return &testConn{server: s}, nil }
+func (s *testServer) Driver() driver.Driver                        { return testDriver{} }
+
+type testDriver struct{}
+
+func (testDriver) Open(string) (driver.Conn, error) { return nil, driver.ErrBadConn }
+
+type testConn struct{ server *testServer }
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{server: c.server, query: query}, nil
+}
+func (c *testConn) Close() error              { return nil }
+func (c *testConn) Begin() (driver.Tx, error) { return testTx{}, nil }
+
+type testTx struct{}
+
+func (testTx) Commit() error   { return nil }
+func (testTx) Rollback() error { return nil }
+
+type testStmt struct {
+	server *testServer
+	query  string
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	r := s.server.next(s.query, args)
+	return testExecResult{r.lastInsertId, r.rowsAffected}, nil
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	r := s.server.next(s.query, args)
+	s.server.mu.Lock()
+	s.server.open++
+	s.server.mu.Unlock()
+	return &testRows{server: s.server, result: r}, nil
+}
+
+type testExecResult struct{ id, n int64 }
+
+func (r testExecResult) LastInsertId() (int64, error) { return r.id, nil }
+func (r testExecResult) RowsAffected() (int64, error) { return r.n, nil }
+
+type testRows struct {
+	server *testServer
+	result testResult
+	i      int
+}
+
+func (r *testRows) Columns() []string { return r.result.columns }
+
+func (r *testRows) Close() error {
+	r.server.mu.Lock()
+	defer r.server.mu.Unlock()
+	r.server.open--
+	return nil
+}
+
+func (r *testRows) ColumnTypeDatabaseTypeName(i int) string {
+	if i < len(r.result.types) {
+		return r.result.types[i]
+	}
+	return ""
+}
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.i >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.i])
+	r.i++
+	return nil
+}
+
+func newTestAdapter() (*Adapter, *testServer) {
+	server := &testServer{}
+	codecs := newCodecRegistry()
+	adapter := &Adapter{
+		db:      sql.OpenDB(server),
+		schemas: newSchemaCache(Naming{}, codecs),
+		codecs:  codecs,
+		loc:     time.UTC,
+		autoinc: &autoincMode{},
+		scopes:  newScopeRegistry(),
+	}
+	adapter.SetLogger(InitLogger(io.Discard))
+	return adapter, server
+}
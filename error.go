@@ -1,5 +1,7 @@
 package mysqldb
 
+import "fmt"
+
 const (
 	NODATA_ERROR                    = "no data was queried."
 	SLICEPOINTER_ERROR              = "needs a pointer to a slice."
@@ -8,4 +10,27 @@ const (
 	PARAMETER_ERROR                 = "parameter error."
 	PARAMETER_FIRST_REQUIRED        = "first parameter cannot be empty."
 	PARAMETER_SECOND_SLICE_REQUIRED = "second parameter needs a slice."
+	DECIMAL_FLOAT_ERROR             = "decimal column cannot be converted from or to float in strict mode."
+	PK_REQUIRED_ERROR               = "every column of a composite primary key is required."
+	SOFTDELETE_ERROR                = "struct has no softdelete column."
+	RELATION_ERROR                  = "unknown or invalid relation."
+	ASSOCIATION_ERROR               = "association needs an entity bound with Model."
+	KEY_ZERO_ERROR                  = "entity key is zero, save it first."
+	TENANT_ERROR                    = "tenant id cannot be empty."
+	TENANT_MISMATCH_ERROR           = "tenant_id does not match the tenant of the adapter."
+	DATABASE_ERROR                  = "database name must be a plain identifier."
+	RAW_SQL_ERROR                   = "raw sql is refused on a tenant or database adapter, call AllowRaw first."
+	TENANT_UPSERT_ERROR             = "upsert is refused on a tenant adapter, the updated row may belong to another tenant."
 )
+
+// ErrStaleObject is returned by Update and Save when the version column of
+// the entity no longer matches the row, because it was changed or deleted
+// since the entity was loaded.
+type ErrStaleObject struct {
+	Table   string
+	Version int64
+}
+
+func (e *ErrStaleObject) Error() string {
+	return fmt.Sprintf("stale object: %s was modified since version %d was loaded.", e.Table, e.Version)
+}
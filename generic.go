@@ -0,0 +1,97 @@
+package mysqldb
+
+import "reflect"
+
+// FirstOf returns the first row selected by model as T, which may be a
+// struct or a pointer to a struct. The table is inferred from T when the
+// model has none.
+func FirstOf[T any](model *Model) (T, error) {
+	var item T
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Ptr {
+		v := reflect.New(t.Elem())
+		if err := model.First(v.Interface()); err != nil {
+			return item, err
+		}
+		return v.Interface().(T), nil
+	}
+	err := model.First(&item)
+	return item, err
+}
+
+// FindOf returns all rows selected by model as a []T, where T may be a
+// struct or a pointer to a struct.
+func FindOf[T any](model *Model) ([]T, error) {
+	list := make([]T, 0)
+	if err := model.Find(&list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Get loads the row of T whose primary key equals id.
+func Get[T any](adapter *Adapter, id interface{}) (T, error) {
+	model := adapter.NewModel()
+	model.bindStruct(reflect.TypeOf((*T)(nil)).Elem())
+	return FirstOf[T](model.Id(id))
+}
+
+// TypedQuery runs the select built by a Model and returns its rows as T.
+type TypedQuery[T any] struct {
+	model *Model
+}
+
+// Query wraps the select built by model so its rows are returned as T, which
+// may be a struct or a pointer to a struct. The table is inferred from T when
+// the model has none.
+func Query[T any](model *Model) *TypedQuery[T] {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		model.bindStruct(t)
+	}
+	return &TypedQuery[T]{model: model}
+}
+
+// First returns the first selected row.
+func (q *TypedQuery[T]) First() (T, error) {
+	return FirstOf[T](q.model)
+}
+
+// Find returns all selected rows.
+func (q *TypedQuery[T]) Find() ([]T, error) {
+	return FindOf[T](q.model)
+}
+
+// Count returns the number of selected rows.
+func (q *TypedQuery[T]) Count() (int64, error) {
+	return q.model.Count()
+}
+
+// QueryRaw runs a native select and scans each row as T, which may be a
+// struct, a pointer to a struct or map[string]interface{}.
+func QueryRaw[T any](model *Model, sql string, args ...interface{}) ([]T, error) {
+	if err := model.checkRaw(); err != nil {
+		return nil, err
+	}
+	rows, err := model.rows(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]T, 0)
+	for rows.Next() {
+		var item T
+		if err := rows.Scan(&item); err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,63 @@
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestQueryRefusedOnTenant(t *testing.T) {
+	adapter, server := newTestAdapter()
+
+	if _, err := QueryRaw[map[string]interface{}](adapter.ForTenant(7).NewModel(), "select * from orders"); err == nil || err.Error() != RAW_SQL_ERROR {
+		t.Fatalf("got %v", err)
+	}
+	if calls := server.sqls(); len(calls) != 0 {
+		t.Fatalf("unexpected statements: %v", calls)
+	}
+
+	server.push(testResult{columns: []string{"id"}})
+	if _, err := QueryRaw[map[string]interface{}](adapter.ForTenant(7).AllowRaw().NewModel(), "select * from orders"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type genericArticle struct {
+	Id    int64  `db:"id,pk"`
+	Cid   int64  `db:"cid"`
+	Title string `db:"title"`
+}
+
+func TestQueryInfersTable(t *testing.T) {
+	adapter, server := newTestAdapter()
+	result := testResult{
+		columns: []string{"id", "cid", "title"},
+		rows:    [][]driver.Value{{int64(1), int64(2), []byte("a")}, {int64(3), int64(2), []byte("b")}},
+	}
+
+	server.push(result)
+	list, err := Query[genericArticle](adapter.NewModel().Where("cid", 2).OrderBy("id desc")).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "select id,cid,title from `generic_article` where cid = ? order by id desc", int64(2))
+	if len(list) != 2 || list[1].Title != "b" {
+		t.Fatalf("got %+v", list)
+	}
+
+	server.push(result)
+	first, err := Query[*genericArticle](adapter.NewModel().Where("cid", 2)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "select id,cid,title from `generic_article` where cid = ? limit ?", int64(2), int64(1))
+	if first.Id != 1 {
+		t.Fatalf("got %+v", first)
+	}
+
+	server.push(testResult{columns: []string{"aggregate"}, types: []string{"BIGINT"}, rows: [][]driver.Value{{int64(2)}}})
+	n, err := Query[genericArticle](adapter.NewModel().Where("cid", 2)).Count()
+	if err != nil || n != 2 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	expectCall(t, server, "select count(*) as aggregate from `generic_article` where cid = ?", int64(2))
+}
@@ -0,0 +1,107 @@
+package mysqldb
+
+import (
+	"reflect"
+)
+
+// Entities implement the hook interfaces to run code around the operations
+// on them. Hooks receive the model running the operation, so queries they
+// make join its transaction; an error returned by a Before hook aborts the
+// operation.
+type BeforeInsertHook interface {
+	BeforeInsert(model *Model) error
+}
+
+type AfterInsertHook interface {
+	AfterInsert(model *Model) error
+}
+
+type BeforeUpdateHook interface {
+	BeforeUpdate(model *Model) error
+}
+
+type AfterUpdateHook interface {
+	AfterUpdate(model *Model) error
+}
+
+type BeforeDeleteHook interface {
+	BeforeDelete(model *Model) error
+}
+
+type AfterDeleteHook interface {
+	AfterDelete(model *Model) error
+}
+
+type AfterFindHook interface {
+	AfterFind(model *Model) error
+}
+
+type hookKind int
+
+const (
+	beforeInsert hookKind = iota
+	afterInsert
+	beforeUpdate
+	afterUpdate
+	beforeDelete
+	afterDelete
+	afterFind
+)
+
+// hook calls the hook of kind on entity, a pointer to a struct, when it
+// implements it. The statement being built is kept intact across the call.
+func (model *Model) hook(kind hookKind, entity interface{}) (err error) {
+	saved := model.statement
+	defer func() {
+		model.statement = saved
+	}()
+
+	switch kind {
+	case beforeInsert:
+		if h, ok := entity.(BeforeInsertHook); ok {
+			return h.BeforeInsert(model)
+		}
+	case afterInsert:
+		if h, ok := entity.(AfterInsertHook); ok {
+			return h.AfterInsert(model)
+		}
+	case beforeUpdate:
+		if h, ok := entity.(BeforeUpdateHook); ok {
+			return h.BeforeUpdate(model)
+		}
+	case afterUpdate:
+		if h, ok := entity.(AfterUpdateHook); ok {
+			return h.AfterUpdate(model)
+		}
+	case beforeDelete:
+		if h, ok := entity.(BeforeDeleteHook); ok {
+			return h.BeforeDelete(model)
+		}
+	case afterDelete:
+		if h, ok := entity.(AfterDeleteHook); ok {
+			return h.AfterDelete(model)
+		}
+	case afterFind:
+		if h, ok := entity.(AfterFindHook); ok {
+			return h.AfterFind(model)
+		}
+	}
+	return nil
+}
+
+// hookEach calls the hook of kind on every element of list, a slice of
+// structs or of pointers to structs.
+func (model *Model) hookEach(kind hookKind, list reflect.Value) error {
+	for i := 0; i < list.Len(); i++ {
+		item := list.Index(i)
+		if item.Kind() != reflect.Ptr {
+			item = item.Addr()
+		} else if item.IsNil() {
+			continue
+		}
+		if err := model.hook(kind, item.Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
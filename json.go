@@ -0,0 +1,50 @@
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// JSON stores V in a JSON column, marshaling it on write and unmarshaling
+// it on read.
+type JSON[T any] struct {
+	V T
+}
+
+func NewJSON[T any](v T) JSON[T] {
+	return JSON[T]{V: v}
+}
+
+func (j JSON[T]) Value() (driver.Value, error) {
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (j *JSON[T]) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		var zero T
+		j.V = zero
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JSON", src)
+	}
+	return json.Unmarshal(b, &j.V)
+}
+
+func (j JSON[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.V)
+}
+
+func (j *JSON[T]) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &j.V)
+}
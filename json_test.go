@@ -0,0 +1,71 @@
+package mysqldb
+
+import "testing"
+
+type jsonDoc struct {
+	Id    int64                  `db:"id,pk"`
+	Attrs map[string]interface{} `db:"attrs,json"`
+}
+
+func TestJSONEncodeError(t *testing.T) {
+	adapter, server := newTestAdapter()
+	doc := &jsonDoc{Id: 1, Attrs: map[string]interface{}{"bad": func() {}}}
+
+	if _, err := adapter.NewModel().Insert(doc); err == nil {
+		t.Fatal("Insert: expected an error")
+	}
+	if _, err := adapter.NewModel().Id(1).Update(doc); err == nil {
+		t.Fatal("Update: expected an error")
+	}
+	if _, err := adapter.Table("json_doc").Insert(map[string]interface{}{"attrs": map[string]interface{}{"bad": make(chan int)}}); err == nil {
+		t.Fatal("Insert map: expected an error")
+	}
+	if calls := server.sqls(); len(calls) != 0 {
+		t.Fatalf("unexpected statements: %v", calls)
+	}
+}
+
+func TestWhereJSON(t *testing.T) {
+	adapter, server := newTestAdapter()
+
+	adapter.Table("json_doc").WhereJSON("attrs", "$.color", "red").FetchAll()
+	expectCall(t, server, "select * from `json_doc` where (json_extract(attrs, ?) = ?)", "$.color", "red")
+
+	adapter.Table("json_doc").WhereJSON("attrs", "$.size", ">=", 3).FetchAll()
+	expectCall(t, server, "select * from `json_doc` where (json_extract(attrs, ?) >= ?)", "$.size", int64(3))
+}
+
+func TestWhereJSONContains(t *testing.T) {
+	adapter, server := newTestAdapter()
+
+	adapter.Table("json_doc").WhereJSONContains("attrs", map[string]interface{}{"color": "red"}).FetchAll()
+	expectCall(t, server, "select * from `json_doc` where (json_contains(attrs, ?))", `{"color":"red"}`)
+
+	adapter.Table("json_doc").WhereJSONContains("attrs", "red", "$.colors").FetchAll()
+	expectCall(t, server, "select * from `json_doc` where (json_contains(attrs, ?, ?))", `"red"`, "$.colors")
+}
+
+func TestWhereJSONOverlaps(t *testing.T) {
+	adapter, server := newTestAdapter()
+
+	adapter.Table("json_doc").Where("id", ">", 1).WhereJSONOverlaps("attrs", []int{1, 2}).FetchAll()
+	expectCall(t, server, "select * from `json_doc` where id > ? and (json_overlaps(attrs, ?))", int64(1), "[1,2]")
+}
+
+func TestWhereJSONInvalid(t *testing.T) {
+	adapter, _ := newTestAdapter()
+	for name, build := range map[string]func(m *Model){
+		"operator": func(m *Model) { m.WhereJSON("attrs", "$.a", "in", 1) },
+		"column":   func(m *Model) { m.WhereJSONOverlaps("", []int{1}) },
+		"value":    func(m *Model) { m.WhereJSONContains("attrs", make(chan int)) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: expected a panic", name)
+				}
+			}()
+			build(adapter.Table("json_doc"))
+		}()
+	}
+}
@@ -18,6 +18,7 @@ type Model struct {
 	statement    Statement
 	isAutoCommit bool
 	isExecuted   bool
+	snapshots    map[interface{}]snapshot
 }
 
 func (model *Model) Init() {
@@ -62,17 +63,23 @@ func (model *Model) WhereIn(field string, values interface{}) *Model {
 	return model
 }
 
+func (model *Model) WhereJSON(column, path string, args ...interface{}) *Model {
+	model.statement.WhereJSON(column, path, args...)
+	return model
+}
+
+func (model *Model) WhereJSONContains(column string, value interface{}, path ...string) *Model {
+	model.statement.WhereJSONContains(column, value, path...)
+	return model
+}
+
+func (model *Model) WhereJSONOverlaps(column string, value interface{}) *Model {
+	model.statement.WhereJSONOverlaps(column, value)
+	return model
+}
+
 func (model *Model) Id(args interface{}) *Model {
-	pk := "id"
-	if model.statement.pk != "" {
-		pk = model.statement.pk
-	}
-	argsType := reflect.ValueOf(args).Kind().String()
-	if argsType == "slice" {
-		model.statement.WhereIn(pk, args)
-	} else {
-		model.statement.Where(pk, args)
-	}
+	model.statement.WherePk(args)
 	return model
 }
 
@@ -91,8 +98,8 @@ func (model *Model) Limit(args ...int) *Model {
 	return model
 }
 
-func (model *Model) SetPk(pk string) *Model {
-	model.statement.SetPk(pk)
+func (model *Model) SetPk(pk ...string) *Model {
+	model.statement.SetPk(pk...)
 	return model
 }
 
@@ -106,6 +113,11 @@ func (model *Model) OrderBy(args string) *Model {
 	return model
 }
 
+func (model *Model) ForUpdate() *Model {
+	model.statement.ForUpdate()
+	return model
+}
+
 func (model *Model) LeftJoin(table, condition string) *Model {
 	model.statement.LeftJoin(table, condition)
 	return model
@@ -137,14 +149,24 @@ func (model *Model) Insert(args interface{}) (id int64, err error) {
 		return 0, errors.New(PARAMETER_ERROR)
 	}
 
+	if t := reflect.TypeOf(args); t.Kind() == reflect.Ptr {
+		if model.statement.TableName == "" {
+			model.bindStruct(t.Elem())
+		}
+		if err := model.hook(beforeInsert, args); err != nil {
+			model.reset()
+			return 0, err
+		}
+	}
+
 	var result driver.Result
 
-	sql, e := model.statement.buildInsert(args)
+	sql, params, e := model.statement.buildInsert(args)
 	if e != nil {
 		return 0, e
 	}
 
-	result, err = model.exec(sql)
+	result, err = model.exec(sql, params...)
 	if err != nil {
 		return 0, errors.New("Insert error: " + err.Error())
 	}
@@ -154,9 +176,46 @@ func (model *Model) Insert(args interface{}) (id int64, err error) {
 		return 0, errors.New("Insert error: " + err.Error())
 	}
 
+	if v := reflect.ValueOf(args); v.Kind() == reflect.Ptr && v.Elem().Kind() == reflect.Struct {
+		model.adapter.setInsertId(v.Elem(), i)
+		if err := model.hook(afterInsert, args); err != nil {
+			return i, err
+		}
+	}
+
 	return i, err
 }
 
+// Upsert inserts args, a map or a pointer to a struct, or updates the row
+// holding the same primary or unique key with its values. Autocreate
+// columns keep their value on update while autoupdate columns are stamped.
+// It returns the affected rows as reported by MySQL: 1 for an insert, 2
+// for an update and 0 for an unchanged row. No hooks are run.
+func (model *Model) Upsert(args interface{}) (int64, error) {
+	if t := reflect.ValueOf(args).Kind().String(); !inSlice(t, []string{"map", "ptr"}) {
+		model.statement.CustomError(PARAMETER_ERROR, 2, 2)
+		return 0, errors.New(PARAMETER_ERROR)
+	}
+	if model.adapter.tenant != nil {
+		model.reset()
+		return 0, errors.New(TENANT_UPSERT_ERROR)
+	}
+	if t := reflect.TypeOf(args); t.Kind() == reflect.Ptr && model.statement.TableName == "" {
+		model.bindStruct(t.Elem())
+	}
+
+	sql, params, err := model.statement.buildUpsert(args)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := model.exec(sql, params...)
+	if err != nil {
+		return 0, errors.New("Upsert error: " + err.Error())
+	}
+	return result.RowsAffected()
+}
+
 func (model *Model) MultiInsert(args interface{}) (int64, error) {
 	v := reflect.ValueOf(args)
 	if v.Kind() != reflect.Slice {
@@ -174,16 +233,26 @@ func (model *Model) MultiInsert(args interface{}) (int64, error) {
 		return 0, nil
 	}
 
+	if t == "ptr" {
+		if model.statement.TableName == "" {
+			model.bindStruct(v.Type().Elem().Elem())
+		}
+		if err := model.hookEach(beforeInsert, v); err != nil {
+			model.reset()
+			return 0, err
+		}
+	}
+
 	var err error
 
-	sql, err := model.statement.buildMultiInsert(args)
+	sql, params, err := model.statement.buildMultiInsert(args)
 	if err != nil {
 		return 0, err
 	}
 
 	var result driver.Result
 
-	result, err = model.exec(sql)
+	result, err = model.exec(sql, params...)
 	if err != nil {
 		return 0, errors.New("Insert error: " + err.Error())
 	}
@@ -193,24 +262,126 @@ func (model *Model) MultiInsert(args interface{}) (int64, error) {
 		return 0, errors.New("Insert error: " + err.Error())
 	}
 
+	if t == "ptr" && v.Type().Elem().Elem().Kind() == reflect.Struct {
+		if first, err := result.LastInsertId(); err == nil {
+			model.adapter.setInsertIds(v, first)
+		}
+		if err := model.hookEach(afterInsert, v); err != nil {
+			return i, err
+		}
+	}
+
 	return i, err
 }
 
-func (model *Model) Delete() (num int64, err error) {
+// Delete removes the rows matching the where conditions, or entity by its
+// primary key when given. Rows of a struct with a softdelete column are
+// marked deleted instead, with the current time in the representation of
+// the column. The struct must be bound: Table alone deletes the rows.
+func (model *Model) Delete(entity ...interface{}) (num int64, err error) {
+	if err := model.bindEntity(entity...); err != nil {
+		return 0, err
+	}
+	return model.deleteHooks(entity, func() (int64, error) {
+		if s := model.statement.schema; s != nil && s.softDelete != nil {
+			return model.markDeleted(s.softDelete.deletedValue(model.adapter.now()))
+		}
+		return model.delete()
+	})
+}
+
+// ForceDelete removes rows even when the struct has a softdelete column.
+func (model *Model) ForceDelete(entity ...interface{}) (int64, error) {
+	if err := model.bindEntity(entity...); err != nil {
+		return 0, err
+	}
+	model.statement.trashed = trashedWith
+	return model.deleteHooks(entity, model.delete)
+}
+
+// deleteHooks runs del between the delete hooks of the optional entity.
+func (model *Model) deleteHooks(entity []interface{}, del func() (int64, error)) (int64, error) {
+	if len(entity) == 0 {
+		return del()
+	}
+	if err := model.hook(beforeDelete, entity[0]); err != nil {
+		model.reset()
+		return 0, err
+	}
+	n, err := del()
+	if err != nil {
+		return n, err
+	}
+	return n, model.hook(afterDelete, entity[0])
+}
+
+// Restore clears the softdelete column of the matching deleted rows.
+func (model *Model) Restore(entity ...interface{}) (int64, error) {
+	if err := model.bindEntity(entity...); err != nil {
+		return 0, err
+	}
+	if s := model.statement.schema; s == nil || s.softDelete == nil {
+		return 0, errors.New(SOFTDELETE_ERROR)
+	}
+	model.statement.trashed = trashedOnly
+	return model.markDeleted(nil)
+}
+
+func (model *Model) delete() (int64, error) {
 	if model.statement.TableName == "" {
 		return 0, errors.New(TABLENAME_ERROR)
 	}
+	if !model.statement.filtered() {
+		return 0, errors.New(WHERE_ERROR)
+	}
 
-	if cond := model.statement.formatWhere(); cond != "" {
-		result, err := model.exec(fmt.Sprintf("DELETE FROM `%s`%s", model.statement.TableName, cond))
-		if err != nil {
-			return 0, err
-		}
-		return result.RowsAffected()
+	cond, params, err := model.statement.prepareWhere()
+	if err != nil {
+		return 0, err
+	}
+	result, err := model.exec(fmt.Sprintf("DELETE FROM %s%s", model.statement.table(), cond), params...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (model *Model) markDeleted(value interface{}) (int64, error) {
+	if model.statement.TableName == "" {
+		return 0, errors.New(TABLENAME_ERROR)
+	}
+	if !model.statement.filtered() {
+		return 0, errors.New(WHERE_ERROR)
 	}
 
-	return 0, errors.New(WHERE_ERROR)
+	column := model.statement.schema.softDelete.column
+	cond, params, err := model.statement.prepareWhere()
+	if err != nil {
+		return 0, err
+	}
+	params = append([]interface{}{value}, params...)
+	result, err := model.exec(fmt.Sprintf("UPDATE %s SET %s = ?%s", model.statement.table(), column, cond), params...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
 
+// bindEntity binds the struct type of the optional entity and matches it by
+// its primary key.
+func (model *Model) bindEntity(entity ...interface{}) error {
+	if len(entity) == 0 {
+		return nil
+	}
+	v := reflect.ValueOf(entity[0])
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New(PARAMETER_ERROR)
+	}
+	model.bindStruct(v.Elem().Type())
+	if key, ok := model.keyValue(v.Elem()); ok {
+		model.Id(key)
+	}
+	return nil
 }
 
 func (model *Model) Update(args interface{}) (n int64, err error) {
@@ -220,16 +391,60 @@ func (model *Model) Update(args interface{}) (n int64, err error) {
 		return 0, errors.New(PARAMETER_ERROR)
 	}
 
-	sql, e := model.statement.buildUpdate(args)
+	if argsType == "ptr" {
+		if model.statement.TableName == "" {
+			model.bindStruct(reflect.TypeOf(args).Elem())
+		}
+		if err := model.hook(beforeUpdate, args); err != nil {
+			model.reset()
+			return 0, err
+		}
+		if dirty, ok := model.dirtyColumns(args); ok {
+			if len(dirty) == 0 {
+				model.reset()
+				return 0, nil
+			}
+			model.statement.dirty = dirty
+		}
+	}
+
+	var version reflect.Value
+	table := model.statement.TableName
+	if argsType == "ptr" {
+		v := reflect.ValueOf(args).Elem()
+		if f := model.adapter.schemas.schemaOf(v.Type()).version; f != nil {
+			version, _ = f.value(v)
+		}
+	}
+
+	sql, params, e := model.statement.buildUpdate(args)
 	if e != nil {
 		return 0, e
 	}
 
-	result, err := model.Exec(sql)
+	result, err := model.exec(sql, params...)
 	if err != nil {
 		return 0, errors.New("Update Error:" + err.Error())
 	}
-	return result.RowsAffected()
+	n, err = result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if version.IsValid() {
+		if n == 0 {
+			return 0, &ErrStaleObject{Table: table, Version: integerOf(version)}
+		}
+		setInteger(version, integerOf(version)+1)
+	}
+	if argsType == "ptr" {
+		if _, ok := model.snapshots[args]; ok {
+			model.track(reflect.ValueOf(args).Elem())
+		}
+		if err := model.hook(afterUpdate, args); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
 }
 
 func (model *Model) First(i interface{}) error {
@@ -237,60 +452,150 @@ func (model *Model) First(i interface{}) error {
 	if val.Kind() != reflect.Struct {
 		return errors.New(PARAMETER_ERROR)
 	}
-	if model.statement.TableName == "" {
-		model.statement.TableName = FormatUpper(val.Type().Name())
+	model.bindStruct(val.Type())
+	schema, preloads := model.statement.schema, model.statement.preloads
+	sql, params, err := model.statement.buildSelect(true)
+	if err != nil {
+		return err
 	}
-	model.statement.fields = ReflectFields(i)
-	sql, params := model.statement.buildSelect(true)
 	params = append(params, 1)
-	list, err := model.Query(sql, params...)
+	rows, err := model.rows(sql, params...)
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	if len(list) == 0 {
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
 		return errors.New(NODATA_ERROR)
 	}
 
-	err = map2struct(i, list[0])
+	err = rows.scanStruct(val)
+	rows.Close()
 	if err != nil {
 		return err
 	}
-
+	if err := model.loadRelations(schema, []reflect.Value{val}, preloads); err != nil {
+		return err
+	}
+	if val.CanAddr() {
+		if err := model.hook(afterFind, val.Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	model.track(val)
 	return nil
 }
 
 func (model *Model) Find(s interface{}) error {
-	sliceValue := reflect.Indirect(reflect.ValueOf(s))
-	if sliceValue.Kind() != reflect.Slice {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
 		return errors.New(SLICEPOINTER_ERROR)
 	}
+	sliceValue := v.Elem()
 
-	iType := reflect.TypeOf(s).Elem().Elem().Elem()
+	iType := sliceValue.Type().Elem()
+	isPtr := iType.Kind() == reflect.Ptr
+	if isPtr {
+		iType = iType.Elem()
+	}
 	if iType.Kind() != reflect.Struct {
 		return errors.New(PARAMETER_ERROR)
 	}
-	iFace := reflect.New(iType).Interface()
+	model.bindStruct(iType)
+	schema, preloads := model.statement.schema, model.statement.preloads
 
-	if model.statement.TableName == "" {
-		model.statement.TableName = FormatUpper(iType.Name())
+	rows, err := model.Rows()
+	if err != nil {
+		return err
 	}
-	model.statement.fields = ReflectFields(iFace)
+	defer rows.Close()
 
-	sql, params := model.statement.buildSelect()
-	list, err := model.Query(sql, params...)
-	if err != nil {
+	list := reflect.MakeSlice(sliceValue.Type(), 0, 0)
+	for rows.Next() {
+		item := reflect.New(iType)
+		if err := rows.scanStruct(item.Elem()); err != nil {
+			return err
+		}
+		if isPtr {
+			list = reflect.Append(list, item)
+		} else {
+			list = reflect.Append(list, item.Elem())
+		}
+	}
+	if err := rows.Err(); err != nil {
 		return err
 	}
+	rows.Close()
 
-	return slice2struct(s, list)
+	sliceValue.Set(list)
+	owners := make([]reflect.Value, 0, sliceValue.Len())
+	for i := 0; i < sliceValue.Len(); i++ {
+		owners = append(owners, reflect.Indirect(sliceValue.Index(i)))
+	}
+	if err := model.loadRelations(schema, owners, preloads); err != nil {
+		return err
+	}
+	if err := model.hookEach(afterFind, sliceValue); err != nil {
+		return err
+	}
+	for i := 0; i < sliceValue.Len(); i++ {
+		model.track(reflect.Indirect(sliceValue.Index(i)))
+	}
+	return nil
+}
+
+// Model binds the struct type of entity, giving the table name, columns and
+// scopes such as soft deletes to the queries that follow. A pointer to a
+// struct is also kept for Association.
+func (model *Model) Model(entity interface{}) *Model {
+	t := reflect.TypeOf(entity)
+	if t == nil {
+		model.statement.Error(PARAMETER_ERROR, true)
+		return model
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		model.statement.Error(PARAMETER_ERROR, true)
+		return model
+	}
+	model.bindStruct(t)
+	if v := reflect.ValueOf(entity); v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+		model.statement.entity = v.Elem()
+	}
+	return model
+}
+
+func (model *Model) WithTrashed() *Model {
+	model.statement.WithTrashed()
+	return model
+}
+
+func (model *Model) OnlyTrashed() *Model {
+	model.statement.OnlyTrashed()
+	return model
+}
+
+func (model *Model) bindStruct(t reflect.Type) {
+	s := model.adapter.schemas.schemaOf(t)
+	if model.statement.TableName == "" {
+		model.statement.TableName = s.table
+	}
+	model.statement.schema = s
 }
 
 func (model *Model) Fetch() (map[string]interface{}, error) {
-	sql, params := model.statement.buildSelect(true)
+	sql, params, err := model.statement.buildSelect(true)
+	if err != nil {
+		return nil, err
+	}
 	params = append(params, 1)
 
-	list, err := model.Query(sql, params...)
+	list, err := model.fetch(sql, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -303,20 +608,36 @@ func (model *Model) Fetch() (map[string]interface{}, error) {
 }
 
 func (model *Model) FetchAll() ([]map[string]interface{}, error) {
-	sql, params := model.statement.buildSelect()
-	return model.Query(sql, params...)
+	sql, params, err := model.statement.buildSelect()
+	if err != nil {
+		return nil, err
+	}
+	return model.fetch(sql, params...)
 }
 
 func (model *Model) Count() (int64, error) {
-	sql, params := model.statement.buildCount()
-	result, err := model.Query(sql, params...)
+	sql, params, err := model.statement.buildCount()
 	if err != nil {
 		return 0, err
 	}
+	result, err := model.fetch(sql, params...)
+	if err != nil {
+		return 0, err
+	}
+	if len(result) == 0 {
+		return 0, nil
+	}
 	return convertInt(result[0]["aggregate"])
 }
 
 func (model *Model) Query(sql string, args ...interface{}) ([]map[string]interface{}, error) {
+	if err := model.checkRaw(); err != nil {
+		return make([]map[string]interface{}, 0), err
+	}
+	return model.fetch(sql, args...)
+}
+
+func (model *Model) fetch(sql string, args ...interface{}) ([]map[string]interface{}, error) {
 	list := make([]map[string]interface{}, 0)
 	rows, err := model.query(sql, args...)
 	if err != nil {
@@ -324,7 +645,7 @@ func (model *Model) Query(sql string, args ...interface{}) ([]map[string]interfa
 	}
 	defer rows.Close()
 
-	return model.resultSet(rows), nil
+	return model.resultSet(rows)
 }
 
 func (model *Model) query(sql string, args ...interface{}) (*sql.Rows, error) {
@@ -361,6 +682,9 @@ func (model *Model) query(sql string, args ...interface{}) (*sql.Rows, error) {
 }
 
 func (model *Model) Exec(sql string, args ...interface{}) (sql.Result, error) {
+	if err := model.checkRaw(); err != nil {
+		return nil, err
+	}
 	return model.exec(sql, args...)
 }
 
@@ -394,32 +718,20 @@ func (model *Model) exec(sql string, args ...interface{}) (sql.Result, error) {
 	return result, nil
 }
 
-func (model *Model) resultSet(rows *sql.Rows) []map[string]interface{} {
+func (model *Model) resultSet(rows *sql.Rows) ([]map[string]interface{}, error) {
 	columns, _ := rows.Columns()
-	count := len(columns)
+	types, _ := rows.ColumnTypes()
 
 	result := make([]map[string]interface{}, 0)
-	values := make([]interface{}, count)
-
-	scanArgs := make([]interface{}, count)
-
-	for i := range values {
-		scanArgs[i] = &values[i]
-	}
 
 	for rows.Next() {
-		rows.Scan(scanArgs...)
-		entry := make(map[string]interface{})
-		for i, col := range values {
-			if isUint8Slice(col) {
-				entry[columns[i]] = string(col.([]byte))
-			} else {
-				entry[columns[i]] = col
-			}
+		entry, err := model.adapter.scanMap(rows, columns, types)
+		if err != nil {
+			return result, err
 		}
 		result = append(result, entry)
 	}
-	return result
+	return result, rows.Err()
 }
 
 func (model *Model) showSQL(start int64, sql string, args ...interface{}) {
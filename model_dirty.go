@@ -0,0 +1,169 @@
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"reflect"
+)
+
+// Change is the value of a column of a tracked entity when it was loaded
+// and its value now, both as bound to the database.
+type Change struct {
+	Old interface{}
+	New interface{}
+}
+
+type snapshot map[string]interface{}
+
+// track remembers the column values of the addressable struct v, loaded
+// through this model, so later updates only send changed columns. The
+// snapshot is kept until Untrack or until the model is dropped.
+func (model *Model) track(v reflect.Value) {
+	if !v.CanAddr() {
+		return
+	}
+	if model.snapshots == nil {
+		model.snapshots = make(map[interface{}]snapshot)
+	}
+	model.snapshots[v.Addr().Interface()] = model.snapshotOf(v)
+}
+
+func (model *Model) snapshotOf(v reflect.Value) snapshot {
+	snap := make(snapshot)
+	for _, f := range model.adapter.schemas.schemaOf(v.Type()).fields {
+		if f.prefix != "" {
+			continue
+		}
+		fv, ok := f.value(v)
+		if !ok {
+			continue
+		}
+		if val, ok, err := model.statement.encodeField(f, fv); err == nil && ok {
+			snap[f.column] = resolve(val)
+		}
+	}
+	return snap
+}
+
+// resolve returns val as the driver receives it, detached from the entity:
+// Valuer results, or their error text, instead of pointers to fields, and
+// copies of slices and maps, which in-place edits would otherwise change in
+// the snapshot too.
+func resolve(val interface{}) interface{} {
+	if valuer, ok := val.(driver.Valuer); ok {
+		if rv := reflect.ValueOf(val); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil
+		}
+		v, err := valuer.Value()
+		if err != nil {
+			return err.Error()
+		}
+		val = v
+	}
+	return detach(reflect.ValueOf(val))
+}
+
+func detach(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return detach(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return v.Interface()
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if e := detach(v.Index(i)); e != nil {
+				c.Index(i).Set(reflect.ValueOf(e))
+			}
+		}
+		return c.Interface()
+	case reflect.Map:
+		if v.IsNil() {
+			return v.Interface()
+		}
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			e := reflect.Zero(v.Type().Elem())
+			if d := detach(iter.Value()); d != nil {
+				e = reflect.ValueOf(d)
+			}
+			c.SetMapIndex(iter.Key(), e)
+		}
+		return c.Interface()
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v.Interface()
+		}
+		c := reflect.New(v.Type().Elem())
+		if d := detach(v.Elem()); d != nil {
+			c.Elem().Set(reflect.ValueOf(d))
+		}
+		return c.Interface()
+	}
+	return v.Interface()
+}
+
+// Untrack forgets the snapshots of entities, or of every entity loaded by
+// the model when none is given. Later updates of them write every column.
+func (model *Model) Untrack(entities ...interface{}) {
+	if len(entities) == 0 {
+		model.snapshots = nil
+		return
+	}
+	for _, entity := range entities {
+		if reflect.ValueOf(entity).Kind() == reflect.Ptr {
+			delete(model.snapshots, entity)
+		}
+	}
+}
+
+// Changes returns the columns of entity changed since it was loaded by
+// First or Find on this model, or last written by Update or Save. It
+// returns nil when the entity is not tracked.
+func (model *Model) Changes(entity interface{}) map[string]Change {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	old, ok := model.snapshots[entity]
+	if !ok {
+		return nil
+	}
+
+	changes := make(map[string]Change)
+	current := model.snapshotOf(v.Elem())
+	for column, val := range current {
+		if prev, ok := old[column]; !ok || !reflect.DeepEqual(prev, val) {
+			changes[column] = Change{Old: prev, New: val}
+		}
+	}
+	for column, prev := range old {
+		if _, ok := current[column]; !ok {
+			changes[column] = Change{Old: prev}
+		}
+	}
+	return changes
+}
+
+// dirtyColumns returns the changed columns of a tracked entity that an
+// UPDATE may write. It reports false when the entity is not tracked.
+func (model *Model) dirtyColumns(entity interface{}) (map[string]bool, bool) {
+	changes := model.Changes(entity)
+	if changes == nil {
+		return nil, false
+	}
+	s := model.adapter.schemas.schemaOf(reflect.TypeOf(entity))
+	dirty := make(map[string]bool, len(changes))
+	for column := range changes {
+		if f, ok := s.field(column); ok && !f.has(flagPk|flagAutoIncr|flagReadOnly|flagAutoCreate) {
+			dirty[f.column] = true
+		}
+	}
+	return dirty, true
+}
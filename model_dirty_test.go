@@ -0,0 +1,121 @@
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type dirtyTags struct {
+	List []string
+}
+
+func (t *dirtyTags) Value() (driver.Value, error) {
+	b, err := json.Marshal(t.List)
+	return string(b), err
+}
+
+func (t *dirtyTags) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, &t.List)
+	case string:
+		return json.Unmarshal([]byte(v), &t.List)
+	}
+	return errors.New("unsupported type")
+}
+
+type dirtyPost struct {
+	Id   int64     `db:"id,pk"`
+	Tags dirtyTags `db:"tags"`
+	Raw  []byte    `db:"raw"`
+}
+
+func loadDirtyPost(t *testing.T) (*Model, *dirtyPost, *testServer) {
+	adapter, server := newTestAdapter()
+	server.push(testResult{
+		columns: []string{"id", "tags", "raw"},
+		rows:    [][]driver.Value{{int64(1), []byte(`["a"]`), []byte("abc")}},
+	})
+	model := adapter.NewModel()
+	var p dirtyPost
+	if err := model.First(&p); err != nil {
+		t.Fatal(err)
+	}
+	server.sqls()
+	return model, &p, server
+}
+
+func TestChangesPointerValuer(t *testing.T) {
+	model, p, server := loadDirtyPost(t)
+	p.Tags.List = []string{"x"}
+
+	changes := model.Changes(p)
+	if c, ok := changes["tags"]; !ok || c.Old != `["a"]` || c.New != `["x"]` {
+		t.Fatalf("got %v", changes)
+	}
+
+	server.push(testResult{rowsAffected: 1})
+	n, err := model.Id(1).Update(p)
+	if err != nil || n != 1 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	calls := server.sqls()
+	if len(calls) != 1 || calls[0].sql != "UPDATE `dirty_post` SET tags = ? WHERE id = ?" {
+		t.Fatalf("got %v", calls)
+	}
+}
+
+func TestChangesBytesEditedInPlace(t *testing.T) {
+	model, p, _ := loadDirtyPost(t)
+	p.Raw[0] = 'x'
+
+	changes := model.Changes(p)
+	if c, ok := changes["raw"]; !ok || string(c.Old.([]byte)) != "abc" || string(c.New.([]byte)) != "xbc" {
+		t.Fatalf("got %v", changes)
+	}
+	if _, ok := changes["tags"]; ok {
+		t.Fatalf("tags reported changed: %v", changes)
+	}
+}
+
+func TestUpdateMapOnTrackingModel(t *testing.T) {
+	model, _, server := loadDirtyPost(t)
+
+	server.push(testResult{rowsAffected: 1})
+	n, err := model.Table("dirty_post").Where("id", 1).Update(map[string]interface{}{"raw": "x"})
+	if err != nil || n != 1 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	expectCall(t, server, "UPDATE `dirty_post` SET raw = ? WHERE id = ?", "x", int64(1))
+}
+
+func TestTrackingOnlyLoadedEntities(t *testing.T) {
+	model, p, server := loadDirtyPost(t)
+
+	server.push(testResult{lastInsertId: 2, rowsAffected: 1})
+	inserted := &dirtyPost{Raw: []byte("new")}
+	if _, err := model.Insert(inserted); err != nil {
+		t.Fatal(err)
+	}
+	server.sqls()
+	if model.Changes(inserted) != nil {
+		t.Fatal("inserted entity is tracked")
+	}
+	if len(model.snapshots) != 1 {
+		t.Fatalf("got %d snapshots", len(model.snapshots))
+	}
+
+	model.Untrack(p, map[string]interface{}{})
+	if model.Changes(p) != nil || len(model.snapshots) != 0 {
+		t.Fatal("entity still tracked after Untrack")
+	}
+
+	p.Raw = []byte("xyz")
+	server.push(testResult{rowsAffected: 1})
+	if _, err := model.Id(1).Update(p); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "UPDATE `dirty_post` SET tags = ?,raw = ? WHERE id = ?", `["a"]`, []byte("xyz"), int64(1))
+}
@@ -0,0 +1,219 @@
+package mysqldb
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Save inserts entity when its primary key is zero and updates the row
+// with its primary key otherwise. When the update matches no row, as for a
+// new entity with a composite or assigned key, the entity is inserted.
+func (model *Model) Save(entity interface{}) error {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New(PARAMETER_ERROR)
+	}
+	model.bindStruct(v.Elem().Type())
+
+	key, ok := model.keyValue(v.Elem())
+	if !ok {
+		_, err := model.Insert(entity)
+		return err
+	}
+
+	return model.atomic(func() error {
+		saved := model.statement
+		n, err := model.Id(key).Update(entity)
+		if err != nil || n > 0 {
+			return err
+		}
+
+		// MySQL reports 0 affected rows for an unchanged row too, so only
+		// insert when the key is really missing.
+		model.restore(saved)
+		found, err := model.Id(key).Count()
+		if err != nil || found > 0 {
+			return err
+		}
+
+		model.restore(saved)
+		_, err = model.Insert(entity)
+		return err
+	})
+}
+
+// FirstOrCreate loads the first row matching conds into entity, or inserts
+// entity with conds applied to it when there is none. It reports whether a
+// row was created.
+//
+// Without ForUpdate the lookup does not lock, so concurrent callers may both
+// insert; a unique index on the conds columns turns the second insert into
+// an error. With ForUpdate the lookup and the insert share a transaction.
+func (model *Model) FirstOrCreate(entity interface{}, conds map[string]interface{}) (created bool, err error) {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return false, errors.New(PARAMETER_ERROR)
+	}
+	if len(conds) == 0 {
+		return false, errors.New(WHERE_ERROR)
+	}
+	model.bindStruct(v.Elem().Type())
+
+	err = model.atomic(func() error {
+		saved := model.statement
+		err := model.Where(conds).First(entity)
+		if err == nil || err.Error() != NODATA_ERROR {
+			return err
+		}
+
+		model.restore(saved)
+		if err := model.fill(v.Elem(), conds); err != nil {
+			return err
+		}
+		if _, err := model.Insert(entity); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+// UpdateOrCreate updates the rows matching conds with values, or inserts
+// conds and values together when there is none. values is a map or a
+// pointer to a struct, as for Update; conds are copied onto the struct. It
+// reports whether a row was created. It races like FirstOrCreate unless
+// ForUpdate is set.
+func (model *Model) UpdateOrCreate(conds map[string]interface{}, values interface{}) (created bool, err error) {
+	if len(conds) == 0 {
+		return false, errors.New(WHERE_ERROR)
+	}
+	data, isMap := values.(map[string]interface{})
+	if !isMap {
+		v := reflect.ValueOf(values)
+		if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+			return false, errors.New(PARAMETER_ERROR)
+		}
+		model.bindStruct(v.Elem().Type())
+		if err := model.fill(v.Elem(), conds); err != nil {
+			return false, err
+		}
+	}
+
+	err = model.atomic(func() error {
+		saved := model.statement
+		model.statement.fields = Keys(conds)
+		row, err := model.Where(conds).Fetch()
+		if err != nil {
+			return err
+		}
+
+		model.restore(saved)
+		if row != nil {
+			_, err = model.Where(conds).Update(values)
+			return err
+		}
+
+		if isMap {
+			insertData := make(map[string]interface{}, len(conds)+len(data))
+			for key, value := range conds {
+				insertData[key] = value
+			}
+			for key, value := range data {
+				insertData[key] = value
+			}
+			_, err = model.Insert(insertData)
+		} else {
+			_, err = model.Insert(values)
+		}
+		if err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+// atomic runs fn in its own transaction when ForUpdate was set outside of
+// one, so the rows locked by the lookup stay locked until the write.
+func (model *Model) atomic(fn func() error) error {
+	if model.statement.lock == "" {
+		return fn()
+	}
+	return model.transaction(fn)
+}
+
+// transaction runs fn in a transaction of its own unless model is already
+// in one.
+func (model *Model) transaction(fn func() error) (err error) {
+	if !model.isAutoCommit {
+		return fn()
+	}
+	if err = model.Begin(); err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			model.Rollback()
+			panic(r)
+		}
+	}()
+	if err = fn(); err != nil {
+		model.Rollback()
+		return err
+	}
+	return model.Commit()
+}
+
+// restore puts back a statement saved before a lookup, which resets it, so
+// the write that follows targets the same table.
+func (model *Model) restore(saved Statement) {
+	model.statement = saved
+	model.statement.lock = ""
+}
+
+// keyValue returns the primary key of the struct v as accepted by Id. It
+// reports false when every key column is zero or not mapped.
+func (model *Model) keyValue(v reflect.Value) (interface{}, bool) {
+	s := model.adapter.schemas.schemaOf(v.Type())
+	keys := model.statement.keyColumns()
+	values := make([]interface{}, 0, len(keys))
+	zero := true
+	for _, key := range keys {
+		f, ok := s.field(key)
+		if !ok {
+			return nil, false
+		}
+		fv, ok := f.value(v)
+		if !ok {
+			return nil, false
+		}
+		if !fv.IsZero() {
+			zero = false
+		}
+		values = append(values, fv.Interface())
+	}
+	if zero {
+		return nil, false
+	}
+	if len(values) == 1 {
+		return values[0], true
+	}
+	return values, true
+}
+
+// fill copies conds onto the matching fields of the struct v.
+func (model *Model) fill(v reflect.Value, conds map[string]interface{}) error {
+	s := model.adapter.schemas.schemaOf(v.Type())
+	for column, value := range conds {
+		f, ok := s.field(column)
+		if !ok || f.prefix != "" {
+			continue
+		}
+		if err := model.adapter.assignField(f, f.settable(v), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
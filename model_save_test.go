@@ -0,0 +1,55 @@
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+type saveMember struct {
+	GroupId int64  `db:"group_id,pk"`
+	UserId  int64  `db:"user_id,pk"`
+	Role    string `db:"role"`
+}
+
+func TestSaveInsertsMissingKey(t *testing.T) {
+	adapter, server := newTestAdapter()
+	member := &saveMember{GroupId: 1, UserId: 2, Role: "owner"}
+
+	server.push(
+		testResult{rowsAffected: 0},
+		testResult{columns: []string{"aggregate"}, types: []string{"BIGINT"}, rows: [][]driver.Value{{int64(0)}}},
+		testResult{rowsAffected: 1},
+	)
+	if err := adapter.NewModel().Save(member); err != nil {
+		t.Fatal(err)
+	}
+	calls := server.sqls()
+	if len(calls) != 3 {
+		t.Fatalf("got %d statements: %v", len(calls), calls)
+	}
+	for i, want := range []string{
+		"UPDATE `save_member` SET role = ? WHERE (group_id = ? AND user_id = ?)",
+		"select count(*) as aggregate from `save_member` where (group_id = ? and user_id = ?)",
+		"INSERT INTO `save_member` (`group_id`,`user_id`,`role`) VALUES (?,?,?)",
+	} {
+		if calls[i].sql != want {
+			t.Fatalf("statement %d: got  %s\nwant %s", i, calls[i].sql, want)
+		}
+	}
+}
+
+func TestSaveKeepsUnchangedRow(t *testing.T) {
+	adapter, server := newTestAdapter()
+	member := &saveMember{GroupId: 1, UserId: 2, Role: "owner"}
+
+	server.push(
+		testResult{rowsAffected: 0},
+		testResult{columns: []string{"aggregate"}, types: []string{"BIGINT"}, rows: [][]driver.Value{{int64(1)}}},
+	)
+	if err := adapter.NewModel().Save(member); err != nil {
+		t.Fatal(err)
+	}
+	if calls := server.sqls(); len(calls) != 2 {
+		t.Fatalf("got %d statements: %v", len(calls), calls)
+	}
+}
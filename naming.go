@@ -0,0 +1,79 @@
+package mysqldb
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy derives table and column names from Go type and field
+// names when they are not given explicitly.
+type NamingStrategy interface {
+	TableName(name string) string
+	ColumnName(name string) string
+}
+
+// Naming is the default NamingStrategy. It converts names with FormatUpper,
+// putting an underscore before every capital (UserID -> user_i_d), and can
+// prefix and pluralize table names. Set Acronyms to keep runs of capitals
+// together instead (UserID -> user_id); existing tables may then need their
+// names given explicitly.
+type Naming struct {
+	TablePrefix string
+	Plural      bool
+	Acronyms    bool
+}
+
+func (n Naming) TableName(name string) string {
+	table := n.convert(name)
+	if n.Plural {
+		table = pluralize(table)
+	}
+	return n.TablePrefix + table
+}
+
+func (n Naming) ColumnName(name string) string {
+	return n.convert(name)
+}
+
+func (n Naming) convert(name string) string {
+	if n.Acronyms {
+		return SnakeCase(name)
+	}
+	return FormatUpper(name)
+}
+
+type tabler interface {
+	TableName() string
+}
+
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+					b.WriteByte('_')
+				}
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func pluralize(s string) string {
+	switch {
+	case s == "":
+		return s
+	case strings.HasSuffix(s, "s"), strings.HasSuffix(s, "x"), strings.HasSuffix(s, "z"),
+		strings.HasSuffix(s, "ch"), strings.HasSuffix(s, "sh"):
+		return s + "es"
+	case strings.HasSuffix(s, "y") && len(s) > 1 && !strings.ContainsRune("aeiou", rune(s[len(s)-2])):
+		return s[:len(s)-1] + "ies"
+	}
+	return s + "s"
+}
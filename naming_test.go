@@ -0,0 +1,22 @@
+package mysqldb
+
+import "testing"
+
+func TestNamingDefaultKeepsFormatUpper(t *testing.T) {
+	cases := []struct {
+		naming        Naming
+		table, column string
+	}{
+		{Naming{}, "user_profile", "user_i_d"},
+		{Naming{Acronyms: true}, "user_profile", "user_id"},
+		{Naming{TablePrefix: "app_", Plural: true}, "app_user_profiles", "user_i_d"},
+	}
+	for _, c := range cases {
+		if got := c.naming.TableName("UserProfile"); got != c.table {
+			t.Errorf("%+v table: got %q, want %q", c.naming, got, c.table)
+		}
+		if got := c.naming.ColumnName("UserID"); got != c.column {
+			t.Errorf("%+v column: got %q, want %q", c.naming, got, c.column)
+		}
+	}
+}
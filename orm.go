@@ -2,7 +2,9 @@ package mysqldb
 
 import (
 	"database/sql"
+	"net/url"
 	"os"
+	"time"
 
 	"fmt"
 
@@ -10,19 +12,24 @@ import (
 )
 
 type Options struct {
-	User         string
-	Password     string
-	Host         string
-	Port         int
-	Database     string
-	Charset      string
-	MaxIdleConns int
-	MaxOpenConns int
-	Debug        bool
+	User          string
+	Password      string
+	Host          string
+	Port          int
+	Database      string
+	Charset       string
+	MaxIdleConns  int
+	MaxOpenConns  int
+	Debug         bool
+	Naming        NamingStrategy
+	ParseTime     bool
+	Loc           *time.Location
+	StringResults bool
+	StrictDecimal bool
 }
 
 func New(options *Options) (*Adapter, error) {
-	db, err := sql.Open("mysql", fmt.Sprintf(
+	dsn := fmt.Sprintf(
 		"%s:%s@tcp(%s:%d)/%s?charset=%s",
 		options.User,
 		options.Password,
@@ -30,7 +37,18 @@ func New(options *Options) (*Adapter, error) {
 		options.Port,
 		options.Database,
 		options.Charset,
-	))
+	)
+
+	loc := time.UTC
+	if options.Loc != nil {
+		loc = options.Loc
+		dsn += "&loc=" + url.QueryEscape(loc.String())
+	}
+	if options.ParseTime {
+		dsn += "&parseTime=true"
+	}
+
+	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -42,9 +60,22 @@ func New(options *Options) (*Adapter, error) {
 	db.SetMaxIdleConns(options.MaxIdleConns)
 	db.SetMaxOpenConns(options.MaxOpenConns)
 
+	codecs := newCodecRegistry()
 	adapter := &Adapter{
-		db:    db,
-		isLog: false,
+		db:      db,
+		isLog:   false,
+		schemas: newSchemaCache(Naming{}, codecs),
+		codecs:  codecs,
+		loc:     loc,
+		autoinc: &autoincMode{},
+		scopes:  newScopeRegistry(),
+	}
+
+	adapter.SetStringResults(options.StringResults)
+	adapter.SetStrictDecimal(options.StrictDecimal)
+
+	if options.Naming != nil {
+		adapter.SetNamingStrategy(options.Naming)
 	}
 
 	logger := InitLogger(os.Stdout)
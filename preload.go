@@ -0,0 +1,276 @@
+package mysqldb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type preload struct {
+	path  string
+	scope func(*Model) *Model
+}
+
+type preloadGroup struct {
+	name   string
+	scope  func(*Model) *Model
+	nested []preload
+}
+
+func (statement *Statement) Preload(names ...string) *Statement {
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name == "" {
+			statement.Error(PARAMETER_ERROR, true)
+			return statement
+		}
+		statement.preloads = append(statement.preloads, preload{path: name})
+	}
+	return statement
+}
+
+func (statement *Statement) PreloadWith(name string, scope func(*Model) *Model) *Statement {
+	if name = strings.TrimSpace(name); name == "" || scope == nil {
+		statement.Error(PARAMETER_ERROR, true)
+		return statement
+	}
+	statement.preloads = append(statement.preloads, preload{path: name, scope: scope})
+	return statement
+}
+
+// Preload loads the named relations of the structs read by First and Find
+// with one query per relation. Nested relations are named with dots, as in
+// "Author.Profile".
+func (model *Model) Preload(names ...string) *Model {
+	model.statement.Preload(names...)
+	return model
+}
+
+// PreloadWith preloads the named relation, letting scope add conditions,
+// ordering or further preloads to its query.
+func (model *Model) PreloadWith(name string, scope func(*Model) *Model) *Model {
+	model.statement.PreloadWith(name, scope)
+	return model
+}
+
+// session returns a model for sub-queries that shares the connection and
+// transaction of model.
+func (model *Model) session() *Model {
+	sub := &Model{
+		db:           model.db,
+		tx:           model.tx,
+		adapter:      model.adapter,
+		isAutoCommit: model.isAutoCommit,
+		isExecuted:   model.isExecuted,
+	}
+	sub.statement.Init()
+	sub.statement.adapter = model.adapter
+	return sub
+}
+
+// loadRelations runs preloads on owners, addressable structs of the type
+// described by s.
+func (model *Model) loadRelations(s *schema, owners []reflect.Value, preloads []preload) error {
+	if len(preloads) == 0 || len(owners) == 0 {
+		return nil
+	}
+
+	order := make([]string, 0, len(preloads))
+	groups := make(map[string]*preloadGroup)
+	for _, p := range preloads {
+		head, rest := p.path, ""
+		if i := strings.IndexByte(p.path, '.'); i >= 0 {
+			head, rest = p.path[:i], p.path[i+1:]
+		}
+		key := strings.ToLower(head)
+		g, ok := groups[key]
+		if !ok {
+			g = &preloadGroup{name: head}
+			groups[key] = g
+			order = append(order, key)
+		}
+		if rest == "" {
+			if p.scope != nil {
+				g.scope = p.scope
+			}
+		} else {
+			g.nested = append(g.nested, preload{path: rest, scope: p.scope})
+		}
+	}
+
+	for _, key := range order {
+		g := groups[key]
+		r, ok := s.relation(g.name)
+		if !ok {
+			return fmt.Errorf("%s: %s", g.name, RELATION_ERROR)
+		}
+		r, err := r.keys(model.adapter.schemas, s)
+		if err != nil {
+			return err
+		}
+		if err := model.loadRelation(s, r, owners, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (model *Model) loadRelation(s *schema, r *relation, owners []reflect.Value, g *preloadGroup) error {
+	related := model.adapter.schemas.schemaOf(r.elem)
+	ownerField, ok := s.field(r.ownerKey())
+	if !ok {
+		return fmt.Errorf("%s: unknown column %s", r.name, r.ownerKey())
+	}
+	relatedField, ok := related.field(r.relatedKey())
+	if !ok {
+		return fmt.Errorf("%s: unknown column %s", r.name, r.relatedKey())
+	}
+
+	keys := distinctKeys(owners, ownerField)
+	if len(keys) == 0 {
+		return nil
+	}
+
+	byOwner := make(map[string][]reflect.Value)
+	if r.kind == manyToMany {
+		pairs, err := model.session().Table(r.pivot).Fields(r.joinForeignKey, r.joinReferences).WhereIn(r.joinForeignKey, keys).FetchAll()
+		if err != nil {
+			return err
+		}
+		relatedKeys := make([]interface{}, 0, len(pairs))
+		seen := make(map[string]bool)
+		for _, pair := range pairs {
+			if k := keyString(pair[r.joinReferences]); !seen[k] {
+				seen[k] = true
+				relatedKeys = append(relatedKeys, pair[r.joinReferences])
+			}
+		}
+		if len(relatedKeys) == 0 {
+			r.assignAll(owners, ownerField, byOwner)
+			return nil
+		}
+		children, err := model.findRelated(r, relatedField.column, relatedKeys, g)
+		if err != nil {
+			return err
+		}
+		byKey := make(map[string]reflect.Value, children.Len())
+		for i := 0; i < children.Len(); i++ {
+			child := children.Index(i)
+			if fv, ok := relatedField.value(child.Elem()); ok {
+				byKey[keyString(fv.Interface())] = child
+			}
+		}
+		for _, pair := range pairs {
+			if child, ok := byKey[keyString(pair[r.joinReferences])]; ok {
+				k := keyString(pair[r.joinForeignKey])
+				byOwner[k] = append(byOwner[k], child)
+			}
+		}
+		r.assignAll(owners, ownerField, byOwner)
+		return nil
+	}
+
+	children, err := model.findRelated(r, relatedField.column, keys, g)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < children.Len(); i++ {
+		child := children.Index(i)
+		if fv, ok := relatedField.value(child.Elem()); ok {
+			k := keyString(fv.Interface())
+			byOwner[k] = append(byOwner[k], child)
+		}
+	}
+	r.assignAll(owners, ownerField, byOwner)
+	return nil
+}
+
+// findRelated reads the related structs whose column is one of keys, as a
+// slice of pointers.
+func (model *Model) findRelated(r *relation, column string, keys []interface{}, g *preloadGroup) (reflect.Value, error) {
+	sub := model.session().Model(reflect.New(r.elem).Interface()).WhereIn(column, keys)
+	if g.scope != nil {
+		sub = g.scope(sub)
+	}
+	sub.statement.preloads = append(sub.statement.preloads, g.nested...)
+
+	list := reflect.New(reflect.SliceOf(reflect.PtrTo(r.elem)))
+	if err := sub.Find(list.Interface()); err != nil {
+		return reflect.Value{}, err
+	}
+	return list.Elem(), nil
+}
+
+func (r *relation) assignAll(owners []reflect.Value, ownerField *field, byOwner map[string][]reflect.Value) {
+	for _, owner := range owners {
+		fv, ok := ownerField.value(owner)
+		if !ok {
+			continue
+		}
+		r.assign(owner, byOwner[keyString(fv.Interface())])
+	}
+}
+
+// assign sets the relation field of owner to items, pointers to related
+// structs.
+func (r *relation) assign(owner reflect.Value, items []reflect.Value) {
+	fv := r.field.settable(owner)
+	if r.many() {
+		list := reflect.MakeSlice(fv.Type(), 0, len(items))
+		for _, item := range items {
+			if fv.Type().Elem().Kind() == reflect.Ptr {
+				list = reflect.Append(list, item)
+			} else {
+				list = reflect.Append(list, item.Elem())
+			}
+		}
+		fv.Set(list)
+		return
+	}
+	if len(items) == 0 {
+		fv.Set(reflect.Zero(fv.Type()))
+		return
+	}
+	if fv.Kind() == reflect.Ptr {
+		fv.Set(items[0])
+	} else {
+		fv.Set(items[0].Elem())
+	}
+}
+
+func distinctKeys(owners []reflect.Value, f *field) []interface{} {
+	keys := make([]interface{}, 0, len(owners))
+	seen := make(map[string]bool)
+	for _, owner := range owners {
+		fv, ok := f.value(owner)
+		if !ok || fv.IsZero() {
+			continue
+		}
+		if fv.Kind() == reflect.Ptr {
+			fv = fv.Elem()
+		}
+		if k := keyString(fv.Interface()); !seen[k] {
+			seen[k] = true
+			keys = append(keys, fv.Interface())
+		}
+	}
+	return keys
+}
+
+// keyString normalizes a key value so keys read from structs and from
+// result maps compare equal whatever their Go type.
+func keyString(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+	if b, ok := rv.Interface().([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(rv.Interface())
+}
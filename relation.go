@@ -0,0 +1,153 @@
+package mysqldb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+type relationKind int
+
+const (
+	belongsTo relationKind = iota + 1
+	hasOne
+	hasMany
+	manyToMany
+)
+
+// relation describes a struct field loaded from another table. Key columns
+// left empty in the tag are derived when the relation is first used, since
+// the related schema may not be parsed yet.
+type relation struct {
+	name           string
+	kind           relationKind
+	field          *field
+	elem           reflect.Type
+	foreignKey     string
+	references     string
+	pivot          string
+	joinForeignKey string
+	joinReferences string
+}
+
+// parseRelation returns the relation declared by the options of a db tag,
+// or nil when the field is a plain column.
+func parseRelation(sf reflect.StructField, index []int, lazy bool, options []string) *relation {
+	r := &relation{name: sf.Name}
+	for _, option := range options {
+		name, value := option, ""
+		if i := strings.IndexByte(option, ':'); i >= 0 {
+			name, value = option[:i], option[i+1:]
+		}
+		switch name {
+		case "belongs_to":
+			r.kind = belongsTo
+		case "has_one":
+			r.kind = hasOne
+		case "has_many":
+			r.kind = hasMany
+		case "many_to_many":
+			r.kind = manyToMany
+			r.pivot = value
+		case "foreignkey":
+			r.foreignKey = value
+		case "references":
+			r.references = value
+		case "joinforeignkey":
+			r.joinForeignKey = value
+		case "joinreferences":
+			r.joinReferences = value
+		}
+	}
+	if r.kind == 0 {
+		return nil
+	}
+
+	t := sf.Type
+	if r.kind == hasMany || r.kind == manyToMany {
+		if t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	r.elem = t
+	r.field = &field{name: sf.Name, index: index, typ: sf.Type, lazy: lazy}
+	return r
+}
+
+func (r *relation) many() bool {
+	return r.kind == hasMany || r.kind == manyToMany
+}
+
+// keys fills in the key columns left to their defaults: the owner's name or
+// the relation's name followed by _id, and primary keys otherwise.
+func (r *relation) keys(c *schemaCache, owner *schema) (*relation, error) {
+	if r.elem.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s: %s", r.name, RELATION_ERROR)
+	}
+	related := c.schemaOf(r.elem)
+	resolved := *r
+	pk := func(s *schema) string {
+		if len(s.pk) == 1 {
+			return s.pk[0].column
+		}
+		return "id"
+	}
+
+	switch r.kind {
+	case belongsTo:
+		if resolved.foreignKey == "" {
+			resolved.foreignKey = c.names().ColumnName(r.name) + "_id"
+		}
+		if resolved.references == "" {
+			resolved.references = pk(related)
+		}
+	case hasOne, hasMany:
+		if resolved.foreignKey == "" {
+			resolved.foreignKey = c.names().ColumnName(owner.name) + "_id"
+		}
+		if resolved.references == "" {
+			resolved.references = pk(owner)
+		}
+	case manyToMany:
+		if resolved.pivot == "" {
+			return nil, fmt.Errorf("%s: %s", r.name, RELATION_ERROR)
+		}
+		if resolved.foreignKey == "" {
+			resolved.foreignKey = pk(owner)
+		}
+		if resolved.references == "" {
+			resolved.references = pk(related)
+		}
+		if resolved.joinForeignKey == "" {
+			resolved.joinForeignKey = c.names().ColumnName(owner.name) + "_id"
+		}
+		if resolved.joinReferences == "" {
+			resolved.joinReferences = c.names().ColumnName(related.name) + "_id"
+		}
+	}
+	return &resolved, nil
+}
+
+// ownerKey and relatedKey name the columns matched on each side: for a
+// belongs_to the owner holds the foreign key, otherwise the related rows do.
+func (r *relation) ownerKey() string {
+	if r.kind == belongsTo || r.kind == manyToMany {
+		return r.foreignKey
+	}
+	return r.references
+}
+
+func (r *relation) relatedKey() string {
+	if r.kind == belongsTo || r.kind == manyToMany {
+		return r.references
+	}
+	return r.foreignKey
+}
+
+func (s *schema) relation(name string) (*relation, bool) {
+	r, ok := s.relations[strings.ToLower(name)]
+	return r, ok
+}
@@ -0,0 +1,177 @@
+package mysqldb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Rows is a forward-only cursor over a select result. Rows are read one at a
+// time so large result sets can be processed with constant memory.
+type Rows struct {
+	model   *Model
+	rows    *sql.Rows
+	columns []string
+	types   []*sql.ColumnType
+}
+
+func (model *Model) Rows() (*Rows, error) {
+	sql, params, err := model.statement.buildSelect()
+	if err != nil {
+		return nil, err
+	}
+	return model.rows(sql, params...)
+}
+
+func (model *Model) rows(sql string, args ...interface{}) (*Rows, error) {
+	rows, err := model.query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+
+	return &Rows{model: model, rows: rows, columns: columns, types: types}, nil
+}
+
+func (r *Rows) Next() bool {
+	return r.rows.Next()
+}
+
+func (r *Rows) Columns() []string {
+	return r.columns
+}
+
+func (r *Rows) Map() (map[string]interface{}, error) {
+	return r.model.adapter.scanMap(r.rows, r.columns, r.types)
+}
+
+func (r *Rows) Scan(dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New(PARAMETER_ERROR)
+	}
+
+	if m, ok := dest.(*map[string]interface{}); ok {
+		entry, err := r.Map()
+		if err != nil {
+			return err
+		}
+		*m = entry
+		return nil
+	}
+
+	v = v.Elem()
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return errors.New(PARAMETER_ERROR)
+	}
+
+	return r.scanStruct(v)
+}
+
+func (r *Rows) Err() error {
+	return r.rows.Err()
+}
+
+func (r *Rows) Close() error {
+	return r.rows.Close()
+}
+
+func (adapter *Adapter) scanMap(rows *sql.Rows, columns []string, types []*sql.ColumnType) (map[string]interface{}, error) {
+	values := make([]interface{}, len(columns))
+	scanArgs := make([]interface{}, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+
+	if err := rows.Scan(scanArgs...); err != nil {
+		return nil, err
+	}
+
+	entry := make(map[string]interface{}, len(columns))
+	for i, col := range values {
+		if adapter.stringResults || i >= len(types) {
+			if isUint8Slice(col) {
+				col = string(col.([]byte))
+			}
+			entry[columns[i]] = col
+			continue
+		}
+		val, err := adapter.columnValue(types[i], col)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %v", columns[i], err)
+		}
+		entry[columns[i]] = val
+	}
+	return entry, nil
+}
+
+// columnValue converts a scanned value to the Go type matching the column:
+// int64 or uint64 for integers, float64 for FLOAT and DOUBLE, string for
+// DECIMAL and text, time.Time for dates, []byte for binary data and nil for
+// NULL.
+func (adapter *Adapter) columnValue(ct *sql.ColumnType, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	name := strings.ToUpper(ct.DatabaseTypeName())
+	unsigned := strings.HasPrefix(name, "UNSIGNED ")
+	name = strings.TrimPrefix(name, "UNSIGNED ")
+
+	switch name {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR":
+		if unsigned {
+			switch n := v.(type) {
+			case uint64:
+				return n, nil
+			case int64:
+				return uint64(n), nil
+			}
+			return strconv.ParseUint(formatString(v), 10, 64)
+		}
+		if n, ok := v.(int64); ok {
+			return n, nil
+		}
+		return strconv.ParseInt(formatString(v), 10, 64)
+	case "FLOAT", "DOUBLE":
+		switch n := v.(type) {
+		case float64:
+			return n, nil
+		case float32:
+			return float64(n), nil
+		}
+		return strconv.ParseFloat(formatString(v), 64)
+	case "DATETIME", "TIMESTAMP", "DATE":
+		return adapter.parseTime(v)
+	case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "BIT", "GEOMETRY":
+		if b, ok := v.([]byte); ok {
+			return b, nil
+		}
+		return []byte(formatString(v)), nil
+	}
+
+	if isUint8Slice(v) {
+		return string(v.([]byte)), nil
+	}
+	return v, nil
+}
@@ -0,0 +1,46 @@
+//go:build go1.23
+
+package mysqldb
+
+import (
+	"iter"
+	"reflect"
+)
+
+// Each streams the select result of model as values of type T, which may be
+// a struct, a pointer to a struct or map[string]interface{}.
+func Each[T any](model *Model) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		t := reflect.TypeOf((*T)(nil)).Elem()
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			model.bindStruct(t)
+		}
+
+		rows, err := model.Rows()
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var item T
+			if err := rows.Scan(&item); err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(item, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+//go:build go1.23
+
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestEach(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(rowsResult)
+
+	var names []string
+	for user, err := range Each[*rowsUser](adapter.Table("user")) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, user.Name)
+	}
+	if len(names) != 2 || names[0] != "ann" || names[1] != "bob" {
+		t.Fatalf("got %v", names)
+	}
+	if n := server.openRows(); n != 0 {
+		t.Fatalf("%d result sets left open", n)
+	}
+}
+
+func TestEachMap(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(rowsResult)
+
+	var ids []interface{}
+	for m, err := range Each[map[string]interface{}](adapter.Table("user")) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, m["id"])
+	}
+	if len(ids) != 2 || ids[0] != int64(1) || ids[1] != int64(2) {
+		t.Fatalf("got %v", ids)
+	}
+}
+
+func TestEachBreakClosesRows(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(rowsResult)
+
+	for user, err := range Each[rowsUser](adapter.Table("user")) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Id != 1 {
+			t.Fatalf("got %+v", user)
+		}
+		break
+	}
+	if n := server.openRows(); n != 0 {
+		t.Fatalf("%d result sets left open", n)
+	}
+}
+
+func TestEachScanError(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(testResult{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{int64(1), []byte("ann")}, {[]byte("x"), []byte("bob")}, {int64(3), []byte("cid")}},
+	})
+
+	var seen, failed int
+	for _, err := range Each[rowsUser](adapter.Table("user")) {
+		if err != nil {
+			failed++
+			continue
+		}
+		seen++
+	}
+	if seen != 1 || failed != 1 {
+		t.Fatalf("got %d rows and %d errors", seen, failed)
+	}
+	if n := server.openRows(); n != 0 {
+		t.Fatalf("%d result sets left open", n)
+	}
+}
+
+func TestEachEmpty(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(testResult{columns: []string{"id", "name"}})
+
+	for user, err := range Each[rowsUser](adapter.Table("user")) {
+		t.Fatalf("got %+v, %v", user, err)
+	}
+	if n := server.openRows(); n != 0 {
+		t.Fatalf("%d result sets left open", n)
+	}
+}
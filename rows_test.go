@@ -0,0 +1,143 @@
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+func TestFetchAllConversionError(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(testResult{
+		columns: []string{"id"},
+		types:   []string{"BIGINT"},
+		rows:    [][]driver.Value{{int64(1)}, {[]byte("not a number")}},
+	})
+
+	list, err := adapter.Table("user").FetchAll()
+	if err == nil {
+		t.Fatalf("expected an error, got %v", list)
+	}
+}
+
+func TestCountConversionError(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(testResult{
+		columns: []string{"aggregate"},
+		types:   []string{"BIGINT"},
+		rows:    [][]driver.Value{{[]byte("x")}},
+	})
+
+	if _, err := adapter.Table("user").Count(); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	server.push(testResult{columns: []string{"aggregate"}, types: []string{"BIGINT"}})
+	n, err := adapter.Table("user").Count()
+	if err != nil || n != 0 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+}
+
+type rowsUser struct {
+	Id   int64  `db:"id,pk"`
+	Name string `db:"name"`
+}
+
+var rowsResult = testResult{
+	columns: []string{"id", "name"},
+	types:   []string{"BIGINT", "VARCHAR"},
+	rows:    [][]driver.Value{{int64(1), []byte("ann")}, {int64(2), []byte("bob")}},
+}
+
+func TestRows(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(rowsResult)
+
+	rows, err := adapter.Table("user").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cols := rows.Columns(); len(cols) != 2 || cols[0] != "id" || cols[1] != "name" {
+		t.Fatalf("columns: got %v", cols)
+	}
+
+	if !rows.Next() {
+		t.Fatal("expected a first row")
+	}
+	var user rowsUser
+	if err := rows.Scan(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Id != 1 || user.Name != "ann" {
+		t.Fatalf("row 0: got %+v", user)
+	}
+
+	if !rows.Next() {
+		t.Fatal("expected a second row")
+	}
+	m, err := rows.Map()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m["id"] != int64(2) || m["name"] != "bob" {
+		t.Fatalf("row 1: got %v", m)
+	}
+
+	if rows.Next() {
+		t.Fatal("expected the end of the result")
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := server.openRows(); n != 0 {
+		t.Fatalf("%d result sets left open", n)
+	}
+}
+
+func TestRowsScanError(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(testResult{
+		columns: []string{"id", "name"},
+		rows:    [][]driver.Value{{[]byte("x"), []byte("ann")}},
+	})
+
+	rows, err := adapter.Table("user").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		t.Fatal("expected a row")
+	}
+	var user rowsUser
+	if err := rows.Scan(&user); err == nil {
+		t.Fatalf("expected an error, got %+v", user)
+	}
+	if err := rows.Scan(user); err == nil || err.Error() != PARAMETER_ERROR {
+		t.Fatalf("non-pointer destination: got %v", err)
+	}
+}
+
+func TestRowsEmpty(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(testResult{columns: []string{"id", "name"}})
+
+	rows, err := adapter.Table("user").Rows()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows.Next() {
+		t.Fatal("expected no rows")
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	rows.Close()
+	if n := server.openRows(); n != 0 {
+		t.Fatalf("%d result sets left open", n)
+	}
+}
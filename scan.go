@@ -0,0 +1,240 @@
+package mysqldb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// fieldScanner scans a single column straight into a struct field.
+type fieldScanner struct {
+	adapter *Adapter
+	column  string
+	field   *field
+	value   reflect.Value
+	root    reflect.Value
+}
+
+func (s *fieldScanner) Scan(src interface{}) error {
+	dst := s.value
+	if !dst.IsValid() {
+		// The field sits behind a pointer which is only allocated once a
+		// non-NULL value arrives, so unmatched outer joins stay nil.
+		if src == nil {
+			return nil
+		}
+		dst = s.field.settable(s.root)
+	}
+	if err := s.adapter.assignField(s.field, dst, src); err != nil {
+		return fmt.Errorf("column %s: %v", s.column, err)
+	}
+	return nil
+}
+
+func (r *Rows) scanStruct(dest reflect.Value) error {
+	adapter := r.model.adapter
+	s := adapter.schemas.schemaOf(dest.Type())
+	args := make([]interface{}, len(r.columns))
+	for i, column := range r.columns {
+		f, ok := s.field(column)
+		if !ok {
+			args[i] = new(sql.RawBytes)
+			continue
+		}
+		if adapter.strictDecimal && isFloat(f.typ) && i < len(r.types) && isDecimalColumn(r.types[i]) {
+			return fmt.Errorf("column %s: %s", column, DECIMAL_FLOAT_ERROR)
+		}
+		if f.lazy {
+			args[i] = &fieldScanner{adapter: adapter, column: column, field: f, root: dest}
+			continue
+		}
+		field := f.settable(dest)
+		if scanner, ok := field.Addr().Interface().(sql.Scanner); ok && !f.has(flagJSON) && adapter.codecs.lookup(f.typ) == nil {
+			args[i] = scanner
+			continue
+		}
+		args[i] = &fieldScanner{adapter: adapter, column: column, field: f, value: field}
+	}
+	return r.rows.Scan(args...)
+}
+
+func isFloat(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64
+}
+
+func isDecimalColumn(ct *sql.ColumnType) bool {
+	name := strings.ToUpper(ct.DatabaseTypeName())
+	return name == "DECIMAL" || name == "UNSIGNED DECIMAL"
+}
+
+func (adapter *Adapter) assignField(f *field, dst reflect.Value, src interface{}) error {
+	if f.has(flagJSON) {
+		return decodeJSON(dst, src)
+	}
+	return adapter.assign(dst, src)
+}
+
+// assign converts a value returned by the driver, or held in a result map,
+// to the type of dst.
+func (adapter *Adapter) assign(dst reflect.Value, src interface{}) error {
+	if ok, err := adapter.decodeArg(dst, src); ok {
+		return err
+	}
+
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	if dst.CanAddr() {
+		if scanner, ok := dst.Addr().Interface().(sql.Scanner); ok {
+			return scanner.Scan(src)
+		}
+	}
+
+	if dst.Type() == timeType {
+		t, err := adapter.parseTime(src)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	}
+
+	if t, ok := src.(time.Time); ok && dst.Kind() == reflect.String {
+		dst.SetString(t.In(adapter.loc).Format(timeFormat))
+		return nil
+	}
+
+	sv := reflect.ValueOf(src)
+	if b, ok := src.([]byte); ok {
+		sv = reflect.ValueOf(append([]byte{}, b...))
+	}
+	if sv.Type().AssignableTo(dst.Type()) {
+		dst.Set(sv)
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return adapter.assign(dst.Elem(), src)
+	case reflect.Interface:
+		dst.Set(sv)
+	case reflect.String:
+		dst.SetString(formatString(src))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(formatString(src))
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(formatString(src), 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(formatString(src), 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(formatString(src), dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	case reflect.Slice:
+		if dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes([]byte(formatString(src)))
+			return nil
+		}
+		fallthrough
+	case reflect.Struct, reflect.Map, reflect.Array:
+		return decodeJSON(dst, src)
+	default:
+		return fmt.Errorf("unsupported type %s", dst.Type())
+	}
+	return nil
+}
+
+// decodeJSON unmarshals a JSON column value into dst. Values that were
+// already decoded, such as nested maps, are re-encoded first.
+func decodeJSON(dst reflect.Value, src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	case string:
+		b = []byte(v)
+	case []byte:
+		b = v
+	default:
+		var err error
+		if b, err = json.Marshal(src); err != nil {
+			return err
+		}
+	}
+	if len(b) == 0 {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	ptr := reflect.New(dst.Type())
+	if err := json.Unmarshal(b, ptr.Interface()); err != nil {
+		return err
+	}
+	dst.Set(ptr.Elem())
+	return nil
+}
+
+const timeFormat = "2006-01-02 15:04:05"
+
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	time.RFC3339Nano,
+}
+
+// parseTime converts a DATETIME, TIMESTAMP or DATE value to time.Time. The
+// driver returns time.Time with parseTime=true and text otherwise, which is
+// interpreted in the adapter location.
+func (adapter *Adapter) parseTime(src interface{}) (time.Time, error) {
+	var s string
+	switch v := src.(type) {
+	case time.Time:
+		return v, nil
+	case []byte:
+		s = string(v)
+	case string:
+		s = v
+	case int64:
+		return time.Unix(v, 0).In(adapter.loc), nil
+	default:
+		return time.Time{}, fmt.Errorf("cannot convert %T to time.Time", src)
+	}
+
+	if s == "" || strings.HasPrefix(s, "0000-00-00") {
+		return time.Time{}, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, adapter.loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as time.Time", s)
+}
@@ -0,0 +1,152 @@
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+)
+
+type scanUser struct {
+	Id      int64   `db:"id,pk" json:"id"`
+	Name    string  `db:"name" json:"name"`
+	Email   string  `db:"email" json:"email"`
+	Age     int     `db:"age" json:"age"`
+	Score   float64 `db:"score" json:"score"`
+	Balance uint64  `db:"balance" json:"balance"`
+}
+
+var scanColumns = []string{"id", "name", "email", "age", "score", "balance"}
+var scanTypes = []string{"BIGINT", "VARCHAR", "VARCHAR", "INT", "DOUBLE", "UNSIGNED BIGINT"}
+
+func scanRows(n int) [][]driver.Value {
+	rows := make([][]driver.Value, n)
+	for i := range rows {
+		rows[i] = []driver.Value{
+			int64(i + 1), []byte(fmt.Sprintf("user %d", i)), []byte(fmt.Sprintf("user%d@example.com", i)),
+			int64(20 + i%50), float64(i) / 3, uint64(math.MaxUint64 - uint64(i)),
+		}
+	}
+	return rows
+}
+
+// jsonScan is how First and Find filled structs before scanStruct: the
+// result maps round-tripped through encoding/json.
+func jsonScan(list interface{}, dest interface{}) error {
+	b, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dest)
+}
+
+func BenchmarkFind(b *testing.B) {
+	adapter, server := newTestAdapter()
+	server.fallback = testResult{columns: scanColumns, types: scanTypes, rows: scanRows(100)}
+
+	b.Run("json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			list, err := adapter.Table("scan_user").FetchAll()
+			if err != nil {
+				b.Fatal(err)
+			}
+			var users []scanUser
+			if err := jsonScan(list, &users); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("scanStruct", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var users []*scanUser
+			if err := adapter.NewModel().Find(&users); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkFirst(b *testing.B) {
+	adapter, server := newTestAdapter()
+	server.fallback = testResult{columns: scanColumns, types: scanTypes, rows: scanRows(1)}
+
+	b.Run("json", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			row, err := adapter.Table("scan_user").Fetch()
+			if err != nil {
+				b.Fatal(err)
+			}
+			var user scanUser
+			if err := jsonScan(row, &user); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("scanStruct", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var user scanUser
+			if err := adapter.NewModel().First(&user); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestScanNumericStrings(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(testResult{
+		columns: []string{"id", "age", "score", "balance"},
+		rows:    [][]driver.Value{{[]byte("9007199254740993"), []byte("-42"), []byte("1.25"), []byte("18446744073709551615")}},
+	})
+
+	var user scanUser
+	if err := adapter.NewModel().First(&user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Id != 9007199254740993 || user.Age != -42 || user.Score != 1.25 || user.Balance != math.MaxUint64 {
+		t.Fatalf("got %+v", user)
+	}
+}
+
+func TestScanBigintUnsigned(t *testing.T) {
+	adapter, server := newTestAdapter()
+	result := testResult{
+		columns: []string{"id", "balance"},
+		types:   []string{"BIGINT", "UNSIGNED BIGINT"},
+		rows: [][]driver.Value{
+			{int64(math.MaxInt64), uint64(math.MaxUint64)},
+			{int64(9007199254740993), []byte("9007199254740993")},
+		},
+	}
+	server.push(result, result)
+
+	var users []*scanUser
+	if err := adapter.NewModel().Find(&users); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("got %d rows", len(users))
+	}
+	if users[0].Id != math.MaxInt64 || users[0].Balance != math.MaxUint64 {
+		t.Fatalf("row 0: got %+v", users[0])
+	}
+	if users[1].Id != 9007199254740993 || users[1].Balance != 9007199254740993 {
+		t.Fatalf("row 1: got %+v", users[1])
+	}
+
+	list, err := adapter.Table("scan_user").FetchAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := list[0]["balance"].(uint64); !ok || v != math.MaxUint64 {
+		t.Fatalf("FetchAll balance: got %#v", list[0]["balance"])
+	}
+	if v, ok := list[1]["id"].(int64); !ok || v != 9007199254740993 {
+		t.Fatalf("FetchAll id: got %#v", list[1]["id"])
+	}
+}
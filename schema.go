@@ -0,0 +1,449 @@
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// encodeFunc converts a field value to the argument bound in a generated
+// statement. It reports false when the value should be skipped.
+type encodeFunc func(v reflect.Value) (interface{}, bool, error)
+
+type fieldFlag uint16
+
+const (
+	flagPk fieldFlag = 1 << iota
+	flagAutoIncr
+	flagOmitEmpty
+	flagReadOnly
+	flagDecimal
+	flagJSON
+	flagVersion
+	flagSoftDelete
+	flagAutoCreate
+	flagAutoUpdate
+	flagMilli
+)
+
+type field struct {
+	name   string
+	column string
+	prefix string
+	index  []int
+	typ    reflect.Type
+	flags  fieldFlag
+	lazy   bool
+	encode encodeFunc
+}
+
+type schema struct {
+	typ        reflect.Type
+	name       string
+	table      string
+	fields     []*field
+	columns    map[string]*field
+	pk         []*field
+	version    *field
+	softDelete *field
+	stamps     []*field
+	relations  map[string]*relation
+}
+
+// schemaCache is shared by an adapter and the handles derived from it, so
+// resetting it after a codec or naming change reaches all of them.
+type schemaCache struct {
+	mu      sync.RWMutex
+	naming  NamingStrategy
+	codecs  *codecRegistry
+	schemas *sync.Map
+}
+
+var defaultSchemas = newSchemaCache(Naming{}, nil)
+
+func newSchemaCache(naming NamingStrategy, codecs *codecRegistry) *schemaCache {
+	return &schemaCache{naming: naming, codecs: codecs, schemas: new(sync.Map)}
+}
+
+// reset drops the parsed schemas, switching to naming when it is not nil.
+func (c *schemaCache) reset(naming NamingStrategy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if naming != nil {
+		c.naming = naming
+	}
+	c.schemas = new(sync.Map)
+}
+
+func (c *schemaCache) names() NamingStrategy {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.naming
+}
+
+func (c *schemaCache) cached() *sync.Map {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.schemas
+}
+
+// schemaOf returns the cached mapping metadata of the struct type t, which
+// may also be given as a pointer type. It is safe for concurrent use.
+func (c *schemaCache) schemaOf(t reflect.Type) *schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	schemas := c.cached()
+	if cached, ok := schemas.Load(t); ok {
+		return cached.(*schema)
+	}
+
+	s := &schema{
+		typ:       t,
+		name:      t.Name(),
+		table:     c.names().TableName(t.Name()),
+		columns:   make(map[string]*field),
+		relations: make(map[string]*relation),
+	}
+	if tab, ok := reflect.New(t).Interface().(tabler); ok {
+		s.table = tab.TableName()
+	}
+	s.parse(c, t, nil, "", false)
+
+	cached, _ := schemas.LoadOrStore(t, s)
+	return cached.(*schema)
+}
+
+// parse collects the columns of t. Embedded structs are flattened into
+// their parent; other struct fields without a tag form a nested group whose
+// columns are prefixed with the field's column name, as produced by joins.
+func (s *schema) parse(c *schemaCache, t reflect.Type, parent []int, prefix string, lazy bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+
+		dbTag, hasDbTag := sf.Tag.Lookup("db")
+		column, options := parseTag(dbTag)
+		if column == "-" {
+			continue
+		}
+		if r := parseRelation(sf, index, lazy, options); r != nil {
+			if prefix == "" && sf.PkgPath == "" {
+				s.relations[strings.ToLower(sf.Name)] = r
+			}
+			continue
+		}
+		if column == "" {
+			column = strings.Split(sf.Tag.Get("json"), ",")[0]
+			if column == "-" {
+				if !hasDbTag {
+					continue
+				}
+				column = ""
+			}
+		}
+		st := sf.Type
+		if st.Kind() == reflect.Ptr {
+			st = st.Elem()
+		}
+		if column == "" && !hasDbTag && st.Kind() == reflect.Struct && !c.isScalar(st) {
+			viaPtr := lazy || sf.Type.Kind() == reflect.Ptr
+			if sf.Anonymous {
+				s.parse(c, st, index, prefix, viaPtr)
+				continue
+			}
+			if prefix == "" && sf.PkgPath == "" {
+				s.parse(c, st, index, c.names().ColumnName(sf.Name), viaPtr)
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		if column == "" {
+			column = c.names().ColumnName(sf.Name)
+		}
+		if prefix != "" {
+			column = prefix + "." + column
+		}
+
+		key := strings.ToLower(column)
+		if _, ok := s.columns[key]; ok {
+			continue
+		}
+
+		f := &field{
+			name:   sf.Name,
+			column: column,
+			prefix: prefix,
+			index:  index,
+			typ:    sf.Type,
+			lazy:   lazy,
+			encode: encoderOf(sf.Type),
+		}
+		for _, option := range options {
+			switch option {
+			case "pk":
+				f.flags |= flagPk
+			case "autoincr":
+				f.flags |= flagAutoIncr
+			case "omitempty":
+				f.flags |= flagOmitEmpty
+			case "readonly":
+				f.flags |= flagReadOnly
+			case "decimal":
+				f.flags |= flagDecimal
+			case "json":
+				f.flags |= flagJSON
+				f.encode = encodeJSON
+			case "version":
+				f.flags |= flagVersion
+			case "softdelete":
+				f.flags |= flagSoftDelete
+			case "autocreate":
+				f.flags |= flagAutoCreate
+			case "autocreate:milli":
+				f.flags |= flagAutoCreate | flagMilli
+			case "autoupdate":
+				f.flags |= flagAutoUpdate
+			case "autoupdate:milli":
+				f.flags |= flagAutoUpdate | flagMilli
+			}
+		}
+		if f.has(flagDecimal) && isFloat(f.typ) {
+			f.encode = encodeFloatDecimal(f.encode)
+		}
+		if f.has(flagPk) && prefix == "" {
+			s.pk = append(s.pk, f)
+		}
+		if f.has(flagVersion) && s.version == nil && prefix == "" && isInteger(f.typ) {
+			s.version = f
+		}
+		if f.has(flagSoftDelete) && s.softDelete == nil && prefix == "" {
+			s.softDelete = f
+		}
+		if f.has(flagAutoCreate|flagAutoUpdate) && prefix == "" && isStampable(f.typ) {
+			s.stamps = append(s.stamps, f)
+		}
+		s.fields = append(s.fields, f)
+		s.columns[key] = f
+	}
+
+	if parent == nil && len(s.pk) == 0 {
+		if f, ok := s.columns["id"]; ok {
+			f.flags |= flagPk
+			s.pk = []*field{f}
+		}
+	}
+}
+
+// parseTag splits a db tag of the form "column,option,option".
+func parseTag(tag string) (string, []string) {
+	if tag == "" {
+		return "", nil
+	}
+	parts := strings.Split(tag, ",")
+	name := strings.TrimSpace(parts[0])
+	options := make([]string, 0, len(parts)-1)
+	for _, option := range parts[1:] {
+		if option = strings.TrimSpace(option); option != "" {
+			options = append(options, option)
+		}
+	}
+	return name, options
+}
+
+func (s *schema) field(column string) (*field, bool) {
+	f, ok := s.columns[strings.ToLower(column)]
+	return f, ok
+}
+
+func (s *schema) pkColumns() []string {
+	columns := make([]string, 0, len(s.pk))
+	for _, f := range s.pk {
+		columns = append(columns, f.column)
+	}
+	return columns
+}
+
+func (s *schema) columnNames() []string {
+	names := make([]string, 0, len(s.fields))
+	for _, f := range s.fields {
+		names = append(names, f.column)
+	}
+	return names
+}
+
+func (f *field) has(flag fieldFlag) bool {
+	return f.flags&flag != 0
+}
+
+// bare returns the column name without the nested group prefix.
+func (f *field) bare() string {
+	if f.prefix == "" {
+		return f.column
+	}
+	return f.column[len(f.prefix)+1:]
+}
+
+// insertable reports whether the field value fv belongs in an INSERT.
+func (f *field) insertable(fv reflect.Value) bool {
+	if f.prefix != "" || f.has(flagReadOnly) {
+		return false
+	}
+	if f.has(flagAutoIncr|flagOmitEmpty) && fv.IsZero() {
+		return false
+	}
+	return true
+}
+
+// updatable reports whether the field value fv belongs in an UPDATE.
+func (f *field) updatable(fv reflect.Value) bool {
+	if f.prefix != "" || f.has(flagPk|flagAutoIncr|flagReadOnly|flagAutoCreate) {
+		return false
+	}
+	if f.has(flagOmitEmpty) && fv.IsZero() {
+		return false
+	}
+	return true
+}
+
+// value returns the field of the struct v for reading. It reports false when
+// the field sits behind a nil embedded pointer.
+func (f *field) value(v reflect.Value) (reflect.Value, bool) {
+	for _, i := range f.index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+// settable returns the field of the struct v for writing, allocating nil
+// embedded pointers on the way.
+func (f *field) settable(v reflect.Value) reflect.Value {
+	for _, i := range f.index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+var (
+	timeType   = reflect.TypeOf(time.Time{})
+	valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// isScalar reports whether values of the struct type t are stored in a
+// single column rather than mapped field by field.
+func (c *schemaCache) isScalar(t reflect.Type) bool {
+	if c.codecs.lookup(t) != nil {
+		return true
+	}
+	return t == timeType || t.Implements(valuerType) || reflect.PtrTo(t).Implements(scannerType)
+}
+
+func encoderOf(t reflect.Type) encodeFunc {
+	if t.Implements(valuerType) {
+		return encodeInterface
+	}
+	if reflect.PtrTo(t).Implements(valuerType) {
+		return func(v reflect.Value) (interface{}, bool, error) {
+			if v.CanAddr() {
+				return v.Addr().Interface(), true, nil
+			}
+			p := reflect.New(t)
+			p.Elem().Set(v)
+			return p.Interface(), true, nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return encodeInterface
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return encodeInterface
+		}
+		return encodeJSON
+	case reflect.Struct:
+		if t == timeType {
+			return encodeInterface
+		}
+		return encodeJSON
+	case reflect.Map, reflect.Array:
+		return encodeJSON
+	case reflect.Ptr:
+		elem := encoderOf(t.Elem())
+		return func(v reflect.Value) (interface{}, bool, error) {
+			if v.IsNil() {
+				return nil, true, nil
+			}
+			return elem(v.Elem())
+		}
+	case reflect.Interface:
+		return func(v reflect.Value) (interface{}, bool, error) {
+			if v.IsNil() {
+				return nil, false, nil
+			}
+			return encoderOf(v.Elem().Type())(v.Elem())
+		}
+	}
+	return func(v reflect.Value) (interface{}, bool, error) {
+		return nil, false, nil
+	}
+}
+
+// encodeFloatDecimal binds a float field mapped to a DECIMAL column as its
+// shortest exact text so MySQL does not round a binary double.
+func encodeFloatDecimal(encode encodeFunc) encodeFunc {
+	return func(v reflect.Value) (interface{}, bool, error) {
+		val, ok, err := encode(v)
+		switch n := val.(type) {
+		case float32:
+			return strconv.FormatFloat(float64(n), 'f', -1, 32), ok, err
+		case float64:
+			return strconv.FormatFloat(n, 'f', -1, 64), ok, err
+		}
+		return val, ok, err
+	}
+}
+
+func encodeInterface(v reflect.Value) (interface{}, bool, error) {
+	return v.Interface(), true, nil
+}
+
+func encodeJSON(v reflect.Value) (interface{}, bool, error) {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, true, nil
+	}
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, false, err
+	}
+	return string(b), true, nil
+}
+
+func encodeValue(value interface{}) (interface{}, bool, error) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return nil, true, nil
+	}
+	return encoderOf(v.Type())(v)
+}
@@ -0,0 +1,110 @@
+package mysqldb
+
+import (
+	"strings"
+	"sync"
+)
+
+type scopeRegistry struct {
+	mu     sync.RWMutex
+	scopes map[string][]func(*Model) *Model
+}
+
+func newScopeRegistry() *scopeRegistry {
+	return &scopeRegistry{scopes: make(map[string][]func(*Model) *Model)}
+}
+
+func (r *scopeRegistry) register(table string, scope func(*Model) *Model) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.scopes[table] = append(r.scopes[table], scope)
+}
+
+func (r *scopeRegistry) lookup(table string) []func(*Model) *Model {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.scopes[table]
+}
+
+// AddGlobalScope adds the Where conditions set by scope to every select,
+// count, update and delete on table, unless the query calls Unscoped.
+func (adapter *Adapter) AddGlobalScope(table string, scope func(*Model) *Model) {
+	if adapter.scopes == nil {
+		adapter.scopes = newScopeRegistry()
+	}
+	adapter.scopes.register(strings.TrimSpace(table), scope)
+}
+
+// Unscoped skips the global scopes of the table.
+func (statement *Statement) Unscoped() *Statement {
+	statement.unscoped = true
+	return statement
+}
+
+func (model *Model) Unscoped() *Model {
+	model.statement.Unscoped()
+	return model
+}
+
+// Scopes applies reusable chains of conditions to the query, in order.
+func (model *Model) Scopes(scopes ...func(*Model) *Model) *Model {
+	for _, scope := range scopes {
+		model = scope(model)
+	}
+	return model
+}
+
+// globalScopes returns the conditions of the global scopes of the table,
+// each one grouped in parentheses. With a join, their columns are
+// qualified with the alias of the table.
+func (statement *Statement) globalScopes() ([]string, []interface{}) {
+	condition := make([]string, 0)
+	params := make([]interface{}, 0)
+	if statement.unscoped || statement.adapter == nil {
+		return condition, params
+	}
+	for _, scope := range statement.adapter.scopes.lookup(statement.TableName) {
+		sub := statement.adapter.NewModel()
+		sub.Table(statement.TableName)
+		sub = scope(sub)
+		if statement.join != "" && statement.alias != "" {
+			sub.statement.qualify(statement.alias)
+		}
+		scoped, scopedParams := sub.statement.whereConditions()
+		if len(scoped) == 0 {
+			continue
+		}
+		condition = append(condition, "AND ("+strings.Trim(strings.Join(scoped, " "), " ")[4:]+")")
+		params = append(params, scopedParams...)
+	}
+	return condition, params
+}
+
+// qualify prefixes the plain column names of the Where, WhereIn and map
+// conditions with alias. Raw and JSON conditions are left as written.
+func (statement *Statement) qualify(alias string) {
+	for i, w := range statement.where {
+		val := w[1].([]interface{})
+		switch {
+		case len(val) >= 2:
+			if field, ok := val[0].(string); ok && identifierPattern.MatchString(field) {
+				args := append([]interface{}{alias + "." + field}, val[1:]...)
+				statement.where[i] = []interface{}{w[0], args}
+			}
+		case len(val) == 1:
+			if m, ok := val[0].(map[string]interface{}); ok {
+				q := make(map[string]interface{}, len(m))
+				for key, value := range m {
+					if identifierPattern.MatchString(key) {
+						key = alias + "." + key
+					}
+					q[key] = value
+				}
+				statement.where[i] = []interface{}{w[0], []interface{}{q}}
+			}
+		}
+	}
+}
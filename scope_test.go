@@ -0,0 +1,18 @@
+package mysqldb
+
+import "testing"
+
+func TestGlobalScopeQualifiedOnJoin(t *testing.T) {
+	adapter, server := newTestAdapter()
+	adapter.AddGlobalScope("post", func(m *Model) *Model {
+		return m.Where("status", 1).WhereIn("kind", []int{2, 3})
+	})
+
+	adapter.Table("post").Where("id", 5).FetchAll()
+	expectCall(t, server, "select * from `post` where (id = ?) and (status = ? and kind in (?,?))",
+		int64(5), int64(1), int64(2), int64(3))
+
+	adapter.Table("post").Join("user", "A.user_id = B.id").Where("B.name", "ann").FetchAll()
+	expectCall(t, server, "select * from `post` as a inner join `user` as b on a.user_id = b.id where (b.name = ?) and (a.status = ? and a.kind in (?,?))",
+		"ann", int64(1), int64(2), int64(3))
+}
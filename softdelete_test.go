@@ -0,0 +1,37 @@
+package mysqldb
+
+import (
+	"testing"
+	"time"
+)
+
+type softPost struct {
+	Id        int64      `db:"id,pk"`
+	DeletedAt *time.Time `db:"deleted_at,softdelete"`
+}
+
+type softComment struct {
+	Id        int64  `db:"id,pk"`
+	DeletedAt *int64 `db:"deleted_at,softdelete"`
+}
+
+func TestSoftDeleteStampsColumnType(t *testing.T) {
+	adapter, server := newTestAdapter()
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	adapter.SetClock(func() time.Time { return now })
+
+	if _, err := adapter.NewModel().Delete(&softPost{Id: 1}); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "UPDATE `soft_post` SET deleted_at = ? WHERE (id = ?) AND deleted_at IS NULL", now, int64(1))
+
+	if _, err := adapter.NewModel().Delete(&softComment{Id: 2}); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "UPDATE `soft_comment` SET deleted_at = ? WHERE (id = ?) AND deleted_at IS NULL", now.Unix(), int64(2))
+
+	if _, err := adapter.Model(&softComment{}).Where("id", 2).Restore(); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "UPDATE `soft_comment` SET deleted_at = ? WHERE (id = ?) AND deleted_at IS NOT NULL", nil, int64(2))
+}
@@ -7,24 +7,39 @@ import (
 	"log"
 	"reflect"
 	"runtime"
-	"strconv"
+	"sort"
 	"strings"
 )
 
+// expr is a where condition given as SQL with its own bound arguments.
+type expr struct {
+	sql  string
+	args []interface{}
+}
+
 type Statement struct {
-	adapter   *Adapter
-	TableName string
-	alias     string
-	pk        string
-	fields    []string
-	join      string
-	where     [][]interface{}
-	whereRaw  string
-	orderBy   string
-	groupBy   string
-	limit     string
-	distinct  string
-	operator  map[string]string
+	adapter    *Adapter
+	TableName  string
+	alias      string
+	pk         []string
+	fields     []string
+	schema     *schema
+	join       string
+	joinTable  string
+	joinParams []interface{}
+	where      [][]interface{}
+	whereRaw   string
+	orderBy    string
+	groupBy    string
+	limit      string
+	distinct   string
+	lock       string
+	dirty      map[string]bool
+	trashed    int
+	unscoped   bool
+	preloads   []preload
+	entity     reflect.Value
+	operator   map[string]string
 }
 
 func (statement *Statement) Table(table string) *Statement {
@@ -45,15 +60,158 @@ func (statement *Statement) As(args string) *Statement {
 	return statement
 }
 
-func (statement *Statement) SetPk(pk string) *Statement {
-	if pk == "" {
+func (statement *Statement) SetPk(pk ...string) *Statement {
+	if len(pk) == 0 {
 		statement.Error(PARAMETER_ERROR, true)
 		return statement
 	}
-	statement.pk = pk
+	keys := make([]string, 0, len(pk))
+	for _, column := range pk {
+		if column = strings.TrimSpace(column); column == "" {
+			statement.Error(PARAMETER_ERROR, true)
+			return statement
+		}
+		keys = append(keys, column)
+	}
+	statement.pk = keys
 	return statement
 }
 
+// keyColumns returns the primary key columns set with SetPk, else those of
+// the bound struct, else id.
+func (statement *Statement) keyColumns() []string {
+	if len(statement.pk) > 0 {
+		return statement.pk
+	}
+	if statement.schema != nil && len(statement.schema.pk) > 0 {
+		return statement.schema.pkColumns()
+	}
+	return []string{"id"}
+}
+
+// WherePk matches rows by primary key. A single key takes a value or a
+// slice of values; a composite key takes a tuple of values in key order, a
+// slice of tuples, or a map of column to value.
+func (statement *Statement) WherePk(args interface{}) *Statement {
+	keys := statement.keyColumns()
+	if m, ok := args.(map[string]interface{}); ok {
+		if len(statement.pk) > 0 || (statement.schema != nil && len(statement.schema.pk) > 0) {
+			for _, key := range keys {
+				if _, ok := m[key]; !ok {
+					statement.Error(PK_REQUIRED_ERROR, true)
+					return statement
+				}
+			}
+		}
+		columns := make([]string, 0, len(m))
+		for column := range m {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+		values := make([]interface{}, 0, len(m))
+		for _, column := range columns {
+			values = append(values, m[column])
+		}
+		return statement.whereTuples(columns, [][]interface{}{values})
+	}
+
+	if len(keys) == 1 {
+		if reflect.ValueOf(args).Kind() == reflect.Slice {
+			return statement.WhereIn(keys[0], args)
+		}
+		return statement.Where(keys[0], args)
+	}
+
+	v := reflect.ValueOf(args)
+	if k := v.Kind(); k != reflect.Slice && k != reflect.Array {
+		statement.Error(PARAMETER_ERROR, true)
+		return statement
+	}
+	tuples := make([][]interface{}, 0)
+	if v.Len() > 0 && isTuple(v.Index(0)) {
+		for i := 0; i < v.Len(); i++ {
+			tuples = append(tuples, iface2Slice(reflect.Indirect(v.Index(i)).Interface()))
+		}
+	} else {
+		tuples = append(tuples, iface2Slice(args))
+	}
+	for _, tuple := range tuples {
+		if len(tuple) != len(keys) {
+			statement.Error(PARAMETER_ERROR, true)
+			return statement
+		}
+	}
+	return statement.whereTuples(keys, tuples)
+}
+
+func (statement *Statement) whereTuples(keys []string, tuples [][]interface{}) *Statement {
+	if len(tuples) == 0 {
+		statement.Error(PARAMETER_ERROR, true)
+		return statement
+	}
+	args := make([]interface{}, 0, len(keys)*len(tuples))
+	for _, tuple := range tuples {
+		args = append(args, tuple...)
+	}
+	if len(tuples) == 1 {
+		return statement.whereExpr(statement.operator["and"], strings.Join(keys, " = ? AND ")+" = ?", args...)
+	}
+	group := "(" + placeholders(len(keys)) + ")"
+	list := strings.TrimSuffix(strings.Repeat(group+",", len(tuples)), ",")
+	return statement.whereExpr(statement.operator["and"], fmt.Sprintf("(%s) IN (%s)", strings.Join(keys, ","), list), args...)
+}
+
+func isTuple(v reflect.Value) bool {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.Array {
+		return true
+	}
+	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8
+}
+
+// isPk reports whether column is one of the key columns set with SetPk.
+func (statement *Statement) isPk(column string) bool {
+	for _, key := range statement.pk {
+		if strings.EqualFold(key, column) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipOnInsert reports whether the column is left out of an INSERT. A single
+// key set with SetPk is assumed to be generated by the database; columns of
+// a composite key are always written.
+func (statement *Statement) skipOnInsert(column string) bool {
+	return len(statement.pk) == 1 && statement.isPk(column)
+}
+
+// checkInsertKeys returns an error when a column of a composite key is
+// missing from the inserted columns.
+func checkInsertKeys(keys []string, columns []string) error {
+	if len(keys) < 2 {
+		return nil
+	}
+	for _, key := range keys {
+		found := false
+		for _, column := range columns {
+			if strings.EqualFold(key, column) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %s", key, PK_REQUIRED_ERROR)
+		}
+	}
+	return nil
+}
+
 func (statement *Statement) Fileds(args ...string) *Statement {
 	statement.fields = args
 	return statement
@@ -131,6 +289,56 @@ func (statement *Statement) WhereNotIn(field string, values interface{}) *Statem
 	return statement
 }
 
+// WhereJSON compares the value at path inside the JSON column with
+// args, given as (value) or (operator, value).
+func (statement *Statement) WhereJSON(column, path string, args ...interface{}) *Statement {
+	operator := "="
+	switch len(args) {
+	case 1:
+	case 2:
+		operator = strings.ToLower(formatString(args[0]))
+		args = args[1:]
+	default:
+		statement.Error(PARAMETER_ERROR, true)
+		return statement
+	}
+	if column == "" || !inSlice(operator, []string{"=", ">", "<", "!=", "<>", ">=", "<=", "like"}) {
+		statement.Error(PARAMETER_ERROR, true)
+		return statement
+	}
+	return statement.whereExpr(statement.operator["and"], fmt.Sprintf("JSON_EXTRACT(%s, ?) %s ?", column, operator), path, args[0])
+}
+
+// WhereJSONContains matches rows whose JSON column, or the value at the
+// optional path inside it, contains value.
+func (statement *Statement) WhereJSONContains(column string, value interface{}, path ...string) *Statement {
+	b, err := json.Marshal(value)
+	if column == "" || err != nil {
+		statement.Error(PARAMETER_ERROR, true)
+		return statement
+	}
+	if len(path) > 0 {
+		return statement.whereExpr(statement.operator["and"], fmt.Sprintf("JSON_CONTAINS(%s, ?, ?)", column), string(b), path[0])
+	}
+	return statement.whereExpr(statement.operator["and"], fmt.Sprintf("JSON_CONTAINS(%s, ?)", column), string(b))
+}
+
+// WhereJSONOverlaps matches rows whose JSON column shares at least one
+// element with value. It requires MySQL 8.0.17 or later.
+func (statement *Statement) WhereJSONOverlaps(column string, value interface{}) *Statement {
+	b, err := json.Marshal(value)
+	if column == "" || err != nil {
+		statement.Error(PARAMETER_ERROR, true)
+		return statement
+	}
+	return statement.whereExpr(statement.operator["and"], fmt.Sprintf("JSON_OVERLAPS(%s, ?)", column), string(b))
+}
+
+func (statement *Statement) whereExpr(joiner, sql string, args ...interface{}) *Statement {
+	statement.where = append(statement.where, []interface{}{joiner, []interface{}{expr{sql: sql, args: args}}})
+	return statement
+}
+
 func (statement *Statement) Limit(args ...int) *Statement {
 	if len(args) == 0 {
 		statement.Error(PARAMETER_ERROR, true)
@@ -165,16 +373,36 @@ func (statement *Statement) OrderBy(args string) *Statement {
 	return statement
 }
 
+const (
+	trashedExclude = iota
+	trashedWith
+	trashedOnly
+)
+
+// WithTrashed includes soft deleted rows.
+func (statement *Statement) WithTrashed() *Statement {
+	statement.trashed = trashedWith
+	return statement
+}
+
+// OnlyTrashed restricts the query to soft deleted rows.
+func (statement *Statement) OnlyTrashed() *Statement {
+	statement.trashed = trashedOnly
+	return statement
+}
+
+// ForUpdate locks the selected rows until the end of the transaction.
+func (statement *Statement) ForUpdate() *Statement {
+	statement.lock = " FOR UPDATE"
+	return statement
+}
+
 func (statement *Statement) LeftJoin(table, condition string) *Statement {
 	if condition == "" {
 		statement.Error(PARAMETER_ERROR)
 		return statement
 	}
-	if statement.alias == "" {
-		statement.alias = "A"
-	}
-	statement.join = fmt.Sprintf(" LEFT JOIN %v AS B ON %v", table, condition)
-	return statement
+	return statement.joinWith("LEFT JOIN", table, condition)
 }
 
 func (statement *Statement) RightJoin(table, condition string) *Statement {
@@ -182,11 +410,7 @@ func (statement *Statement) RightJoin(table, condition string) *Statement {
 		statement.Error(PARAMETER_ERROR)
 		return statement
 	}
-	if statement.alias == "" {
-		statement.alias = "A"
-	}
-	statement.join = fmt.Sprintf(" RIGHT JOIN %v AS B ON %v", table, condition)
-	return statement
+	return statement.joinWith("RIGHT JOIN", table, condition)
 }
 
 func (statement *Statement) Join(table, condition string) *Statement {
@@ -194,11 +418,7 @@ func (statement *Statement) Join(table, condition string) *Statement {
 		statement.Error(PARAMETER_ERROR)
 		return statement
 	}
-	if statement.alias == "" {
-		statement.alias = "A"
-	}
-	statement.join = fmt.Sprintf(" INNER JOIN %v AS B ON %v", table, condition)
-	return statement
+	return statement.joinWith("INNER JOIN", table, condition)
 }
 
 func (statement *Statement) FullJoin(table, condition string) *Statement {
@@ -206,10 +426,22 @@ func (statement *Statement) FullJoin(table, condition string) *Statement {
 		statement.Error(PARAMETER_ERROR)
 		return statement
 	}
+	return statement.joinWith("FULL JOIN", table, condition)
+}
+
+// joinWith joins table as B. On a tenant adapter the joined rows are
+// restricted to the tenant as well.
+func (statement *Statement) joinWith(kind, table, condition string) *Statement {
 	if statement.alias == "" {
 		statement.alias = "A"
 	}
-	statement.join = fmt.Sprintf(" FULL JOIN %v AS B ON %v", table, condition)
+	statement.joinParams = nil
+	if statement.adapter != nil && statement.adapter.tenant != nil {
+		condition = fmt.Sprintf("(%s) AND B.%s = ?", condition, tenantColumn)
+		statement.joinParams = []interface{}{statement.adapter.tenant.id}
+	}
+	statement.join = fmt.Sprintf(" %s %v AS B ON %v", kind, statement.quoteTable(table), condition)
+	statement.joinTable = strings.TrimSpace(table)
 	return statement
 }
 
@@ -218,25 +450,69 @@ func (statement *Statement) parseField() string {
 		return statement.distinct
 	}
 	if len(statement.fields) == 0 {
+		if statement.schema != nil {
+			return statement.schemaFields()
+		}
 		return "*"
 	} else {
 		return strings.Join(statement.fields, ",")
 	}
 }
 
+// schemaFields lists the columns of the bound struct. With a join, own
+// columns are qualified with the table alias and nested groups are selected
+// from the table they are named after, aliased to their prefixed name.
+func (statement *Statement) schemaFields() string {
+	fields := make([]string, 0, len(statement.schema.fields))
+	for _, f := range statement.schema.fields {
+		if f.prefix == "" {
+			if statement.join != "" {
+				fields = append(fields, statement.alias+"."+f.column)
+			} else {
+				fields = append(fields, f.column)
+			}
+			continue
+		}
+		if qualifier, ok := statement.qualifier(f.prefix); ok {
+			fields = append(fields, fmt.Sprintf("%s.%s AS `%s`", qualifier, f.bare(), f.column))
+		}
+	}
+	if len(fields) == 0 {
+		return "*"
+	}
+	return strings.Join(fields, ",")
+}
+
+func (statement *Statement) qualifier(name string) (string, bool) {
+	table := ""
+	if list := strings.Fields(statement.TableName); len(list) > 0 {
+		table = list[0]
+	}
+	switch {
+	case statement.join != "" && (strings.EqualFold(name, statement.joinTable) || strings.EqualFold(name, "B")):
+		return "B", true
+	case strings.EqualFold(name, table) || (statement.alias != "" && strings.EqualFold(name, statement.alias)):
+		if statement.alias != "" {
+			return statement.alias, true
+		}
+		return table, true
+	}
+	return "", false
+}
+
 func (statement *Statement) parseTableName() string {
 	if statement.TableName == "" {
 		statement.Error(TABLENAME_ERROR, true)
 	}
 	if statement.alias != "" {
-		statement.TableName = statement.TableName + " AS " + statement.alias
+		return statement.table() + " AS " + statement.alias
 	}
-	return statement.TableName
+	return statement.table()
 }
 
 func (statement *Statement) Init() {
 	statement.TableName = ""
-	statement.pk = ""
+	statement.pk = nil
 	statement.fields = []string{}
 	statement.where = [][]interface{}{}
 	statement.whereRaw = ""
@@ -244,7 +520,16 @@ func (statement *Statement) Init() {
 	statement.orderBy = ""
 	statement.groupBy = ""
 	statement.join = ""
+	statement.joinTable = ""
+	statement.joinParams = nil
+	statement.schema = nil
 	statement.distinct = ""
+	statement.lock = ""
+	statement.dirty = nil
+	statement.trashed = trashedExclude
+	statement.unscoped = false
+	statement.preloads = nil
+	statement.entity = reflect.Value{}
 	statement.operator = map[string]string{
 		"eq":  "=",
 		"gt":  ">",
@@ -258,7 +543,80 @@ func (statement *Statement) Init() {
 		"or":  "OR"}
 }
 
-func (statement *Statement) prepareWhere() (string, []interface{}) {
+func (statement *Statement) prepareWhere() (string, []interface{}, error) {
+	condition, params := statement.whereConditions()
+
+	if scopes, scopeParams := statement.scopeConditions(); len(scopes) > 0 {
+		if len(condition) > 0 {
+			user := strings.Trim(strings.Join(condition, " "), " ")[4:]
+			condition = []string{"AND (" + user + ")"}
+		}
+		condition = append(condition, scopes...)
+		params = append(params, scopeParams...)
+	}
+
+	for i, param := range params {
+		val, ok, err := statement.adapter.encodeArg(param)
+		if err != nil {
+			return "", nil, err
+		}
+		if ok {
+			params[i] = val
+		}
+	}
+
+	cond := ""
+	if len(condition) > 0 {
+		cond = strings.Trim(strings.Join(condition, " "), " ")[4:]
+	}
+
+	if cond == "" {
+		return cond, params, nil
+	}
+
+	return fmt.Sprintf(" WHERE %s", cond), params, nil
+}
+
+// filtered reports whether the statement has a where condition of its own,
+// not counting the conditions added by scopes.
+func (statement *Statement) filtered() bool {
+	condition, _ := statement.whereConditions()
+	return len(condition) > 0
+}
+
+// scopeConditions returns the conditions every query on the table or the
+// bound struct gets: hiding soft deleted rows, the tenant and global scopes.
+func (statement *Statement) scopeConditions() ([]string, []interface{}) {
+	condition := make([]string, 0)
+	params := make([]interface{}, 0)
+	if statement.schema != nil && statement.schema.softDelete != nil {
+		column := statement.column(statement.schema.softDelete.column)
+		switch statement.trashed {
+		case trashedExclude:
+			condition = append(condition, "AND "+column+" IS NULL")
+		case trashedOnly:
+			condition = append(condition, "AND "+column+" IS NOT NULL")
+		}
+	}
+	if statement.adapter != nil && statement.adapter.tenant != nil {
+		condition = append(condition, "AND "+statement.column(tenantColumn)+" = ?")
+		params = append(params, statement.adapter.tenant.id)
+	}
+	scoped, scopedParams := statement.globalScopes()
+	condition = append(condition, scoped...)
+	params = append(params, scopedParams...)
+	return condition, params
+}
+
+// column qualifies a column of the main table with its alias when joined.
+func (statement *Statement) column(name string) string {
+	if statement.join != "" && statement.alias != "" {
+		return statement.alias + "." + name
+	}
+	return name
+}
+
+func (statement *Statement) whereConditions() ([]string, []interface{}) {
 	condition := make([]string, 0)
 	params := make([]interface{}, 0)
 	for _, v := range statement.where {
@@ -281,9 +639,13 @@ func (statement *Statement) prepareWhere() (string, []interface{}) {
 			case map[string]interface{}:
 				whereMap := make([]string, 0)
 				for key, val := range v {
-					whereMap = append(whereMap, key+" = "+singleQuotes(val))
+					whereMap = append(whereMap, key+" = ?")
+					params = append(params, val)
 				}
 				condition = append(condition, joiner+" ("+strings.Join(whereMap, " AND ")+")")
+			case expr:
+				condition = append(condition, joiner+" ("+v.sql+")")
+				params = append(params, v.args...)
 			}
 		default:
 			c, b := statement.bindParams(val)
@@ -296,16 +658,7 @@ func (statement *Statement) prepareWhere() (string, []interface{}) {
 		condition = append(condition, "AND "+statement.whereRaw)
 	}
 
-	cond := ""
-	if len(condition) > 0 {
-		cond = strings.Trim(strings.Join(condition, " "), " ")[4:]
-	}
-
-	if cond == "" {
-		return cond, params
-	}
-
-	return fmt.Sprintf(" WHERE %s", cond), params
+	return condition, params
 }
 
 func (statement *Statement) bindParams(args []interface{}) (string, []interface{}) {
@@ -349,266 +702,244 @@ func (statement *Statement) bindParams(args []interface{}) (string, []interface{
 	return fmt.Sprintf("%s %s ?", args[0], args[1]), []interface{}{args[2]}
 }
 
-func (statement *Statement) formatWhere() string {
-	condition := make([]string, 0)
-	for _, args := range statement.where {
-		joiner := args[0].(string)
-		params := args[1].([]interface{})
-
-		if empty(params[0]) {
-			continue
-		}
-
-		l := len(params)
-		switch l {
-		case 1:
-			switch v := params[0].(type) {
-			case string:
-				condition = append(condition, fmt.Sprintf("%s (%s)", joiner, v))
-			case map[string]interface{}:
-				whereMap := make([]string, 0)
-				for key, val := range v {
-					whereMap = append(whereMap, fmt.Sprintf("%s = %s", key, singleQuotes(formatString(val))))
-				}
-				condition = append(condition, fmt.Sprintf("%s %s", joiner, strings.Join(whereMap, " AND ")))
-			}
-		case 2:
-			condition = append(condition, fmt.Sprintf("%s %s", joiner, statement.formatParams(params)))
-		case 3:
-			condition = append(condition, fmt.Sprintf("%s %s", joiner, statement.formatParams(params)))
-		}
-	}
-
-	if statement.whereRaw != "" {
-		condition = append(condition, "AND "+statement.whereRaw)
-	}
-
-	cond := ""
-	if len(condition) > 0 {
-		cond = strings.Trim(strings.Join(condition, " "), " ")[4:]
-	}
-
-	if cond == "" {
-		return cond
-	}
-
-	return " WHERE " + cond
-}
-
-func (statement *Statement) formatParams(args []interface{}) string {
-	l := len(args)
-
-	operator := []string{"=", ">", "<", "!=", "<>", ">=", "<=", "like", "in", "not in"}
-
-	var params []string
-
-	if l == 2 {
-		params = append(params, args[0].(string))
-		params = append(params, "=")
-		params = append(params, singleQuotes(args[1]))
-	}
-
-	if l == 3 {
-		operStr := strings.ToLower(formatString(args[1]))
-		if !inSlice(operStr, operator) {
-			panic("FormatParams method: where condition operator error")
-		}
-		params = append(params, args[0].(string))
-		params = append(params, args[1].(string))
-
-		switch operStr {
-		case "in":
-			params = append(params, "("+implode(args[2], ",")+")")
-		case "not in":
-			params = append(params, "("+implode(args[2], ",")+")")
-		case "like":
-			params = append(params, singleQuotes("%"+formatString(args[2])+"%"))
-		default:
-			params = append(params, singleQuotes(args[2]))
-		}
-	}
-
-	return strings.Join(params, " ")
-}
-
-func (statement *Statement) buildSelect(args ...bool) (string, []interface{}) {
+func (statement *Statement) buildSelect(args ...bool) (string, []interface{}, error) {
 	if statement.alias == "" && statement.join != "" {
 		statement.adapter.logger.Errorf("%s alias is empty", statement.TableName)
 		log.Panicln(statement.TableName + " alias is empty")
 	}
 	sql := ""
-	cond, params := statement.prepareWhere()
+	cond, params, err := statement.prepareWhere()
+	if err != nil {
+		return "", nil, err
+	}
+	params = append(append([]interface{}{}, statement.joinParams...), params...)
 	if len(args) == 0 {
 		sql = fmt.Sprintf(
-			"SELECT %v FROM %s%v%v%v%v%v", statement.parseField(), statement.parseTableName(), statement.join, cond, statement.groupBy, statement.orderBy, statement.limit,
+			"SELECT %v FROM %s%v%v%v%v%v%v", statement.parseField(), statement.parseTableName(), statement.join, cond, statement.groupBy, statement.orderBy, statement.limit, statement.lock,
 		)
 	} else {
 		sql = fmt.Sprintf(
-			"SELECT %v FROM %v%v%v%v%v LIMIT ?", statement.parseField(), statement.parseTableName(), statement.join, cond, statement.groupBy, statement.orderBy,
+			"SELECT %v FROM %v%v%v%v%v LIMIT ?%v", statement.parseField(), statement.parseTableName(), statement.join, cond, statement.groupBy, statement.orderBy, statement.lock,
 		)
 	}
-	return strings.ToLower(sql), params
+	return lowerUnquoted(sql), params, nil
 }
 
-func (statement *Statement) buildCount() (string, []interface{}) {
+func (statement *Statement) buildCount() (string, []interface{}, error) {
 	if statement.alias == "" && statement.join != "" {
 		statement.adapter.logger.Errorf("%s alias is empty", statement.TableName)
 		panic(statement.TableName + " alias is empty")
 	}
 
 	sql := ""
-	cond, params := statement.prepareWhere()
+	cond, params, err := statement.prepareWhere()
+	if err != nil {
+		return "", nil, err
+	}
+	params = append(append([]interface{}{}, statement.joinParams...), params...)
 
 	if statement.distinct == "" {
 		sql = fmt.Sprintf(
-			"SELECT COUNT(*) AS aggregate FROM %v%v%v%v%v%v", statement.TableName, statement.join, cond, statement.groupBy, statement.orderBy, statement.limit,
+			"SELECT COUNT(*) AS aggregate FROM %v%v%v%v%v%v", statement.table(), statement.join, cond, statement.groupBy, statement.orderBy, statement.limit,
 		)
 	} else {
 		sql = fmt.Sprintf(
-			"SELECT COUNT(%s) AS aggregate FROM %v%v%v%v%v%v", statement.distinct, statement.TableName, statement.join, cond, statement.groupBy, statement.orderBy, statement.limit,
+			"SELECT COUNT(%s) AS aggregate FROM %v%v%v%v%v%v", statement.distinct, statement.table(), statement.join, cond, statement.groupBy, statement.orderBy, statement.limit,
 		)
 	}
-	return strings.ToLower(sql), params
+	return lowerUnquoted(sql), params, nil
+}
+
+func (statement *Statement) encodeField(f *field, fv reflect.Value) (interface{}, bool, error) {
+	if val, ok, err := statement.adapter.encodeArg(fv.Interface()); ok || err != nil {
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %v", f.column, err)
+		}
+		return val, true, nil
+	}
+	if statement.adapter.strictDecimal && f.has(flagDecimal) && isFloat(f.typ) {
+		return nil, false, fmt.Errorf("%s: %s", f.column, DECIMAL_FLOAT_ERROR)
+	}
+	val, ok, err := f.encode(fv)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %v", f.column, err)
+	}
+	return val, ok, nil
+}
+
+func (statement *Statement) encodeValue(key string, value interface{}) (interface{}, bool, error) {
+	if val, ok, err := statement.adapter.encodeArg(value); ok || err != nil {
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %v", key, err)
+		}
+		return val, true, nil
+	}
+	val, ok, err := encodeValue(value)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %v", key, err)
+	}
+	return val, ok, nil
+}
+
+func (statement *Statement) buildInsert(args interface{}) (string, []interface{}, error) {
+	fields, values, _, err := statement.insertColumns(args)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s)", statement.table(), "`"+strings.Join(fields, "`,`")+"`", placeholders(len(values)),
+	), values, nil
+}
+
+// buildUpsert builds an INSERT that updates the row holding the same key
+// instead. On conflict every inserted column but the keys and the
+// autocreate timestamps takes the new value.
+func (statement *Statement) buildUpsert(args interface{}) (string, []interface{}, error) {
+	fields, values, keys, err := statement.insertColumns(args)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(fields) == 0 {
+		return "", nil, errors.New(PARAMETER_ERROR)
+	}
+	s := statement.schema
+	if reflect.ValueOf(args).Kind() == reflect.Ptr {
+		s = statement.adapter.schemas.schemaOf(reflect.TypeOf(args))
+	}
+
+	updates := make([]string, 0, len(fields))
+	for _, column := range fields {
+		if inSlice(column, keys) || statement.isPk(column) {
+			continue
+		}
+		if s != nil {
+			if f, ok := s.field(column); ok && f.has(flagAutoCreate|flagPk|flagAutoIncr) {
+				continue
+			}
+		}
+		updates = append(updates, fmt.Sprintf("`%s` = VALUES(`%s`)", column, column))
+	}
+	if len(updates) == 0 {
+		updates = append(updates, fmt.Sprintf("`%s` = `%s`", fields[0], fields[0]))
+	}
+
+	return fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s", statement.table(), "`"+strings.Join(fields, "`,`")+"`", placeholders(len(values)), strings.Join(updates, ","),
+	), values, nil
 }
 
-func (statement *Statement) buildInsert(args interface{}) (string, error) {
+// insertColumns returns the columns and values an INSERT of args writes,
+// and the key columns of the table.
+func (statement *Statement) insertColumns(args interface{}) ([]string, []interface{}, []string, error) {
 	fields := make([]string, 0)
-	values := make([]string, 0)
+	values := make([]interface{}, 0)
+	keys := statement.keyColumns()
 
 	t := reflect.ValueOf(args).Kind().String()
 
 	if t == "ptr" {
 		v := reflect.ValueOf(args).Elem()
-		t := v.Type()
-		for i := 0; i < v.NumField(); i++ {
-			f := v.Field(i)
-			setKey := t.Field(i).Tag.Get("json")
-			setVal := v.Field(i)
-			if setKey == "" && f.Kind() != reflect.Struct {
-				setKey = FormatUpper(t.Field(i).Name)
+		s := statement.adapter.schemas.schemaOf(v.Type())
+		s.stamp(v, statement.adapter.now(), true)
+		if err := statement.fillTenant(s, v); err != nil {
+			return nil, nil, nil, err
+		}
+		keys = s.pkColumns()
+		for _, f := range s.fields {
+			if statement.skipOnInsert(f.column) {
+				continue
 			}
-			if statement.pk != "" {
-				if setKey == statement.pk {
-					continue
-				}
+			fv, ok := f.value(v)
+			if !ok || !f.insertable(fv) {
+				continue
 			}
-
-			switch f.Kind() {
-			case reflect.Invalid:
+			val, ok, err := statement.encodeField(f, fv)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if !ok {
 				continue
-			case reflect.Int, reflect.Int32, reflect.Int64:
-				values = append(values, fmt.Sprintf("%v", setVal))
-			case reflect.String:
-				values = append(values, fmt.Sprintf("%v", escapeString(setVal.String())))
-			case reflect.Float32, reflect.Float64:
-				values = append(values, strconv.FormatFloat(setVal.Float(), 'f', -1, 64))
-			case reflect.Struct, reflect.Ptr, reflect.Map:
-				values = append(values, escapeString(json_encode(setVal.Interface())))
-			case reflect.Interface:
-				if v.IsNil() {
-					continue
-				} else {
-					values = append(values, fmt.Sprintf("%v", v.Interface()))
-				}
 			}
-
-			fields = append(fields, setKey)
+			fields = append(fields, f.column)
+			values = append(values, val)
 		}
 	} else {
 		insertData, ok := args.(map[string]interface{})
 		if !ok {
-			return "", errors.New(PARAMETER_ERROR)
-		}
-		if statement.pk != "" {
-			delete(insertData, statement.pk)
+			return nil, nil, nil, errors.New(PARAMETER_ERROR)
 		}
+		insertData = statement.stampMap(insertData, statement.adapter.now(), true)
 		for key, value := range insertData {
-			v := reflect.ValueOf(value)
-			switch v.Kind() {
-			case reflect.Invalid:
+			if statement.skipOnInsert(key) {
+				continue
+			}
+			val, ok, err := statement.encodeValue(key, value)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			if !ok {
 				continue
-			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-				values = append(values, strconv.FormatInt(v.Int(), 10))
-			case reflect.String:
-				values = append(values, escapeString(v.String()))
-			case reflect.Float32:
-				values = append(values, strconv.FormatFloat(v.Float(), 'f', -1, 32))
-			case reflect.Float64:
-				values = append(values, strconv.FormatFloat(v.Float(), 'f', -1, 64))
-			case reflect.Struct, reflect.Ptr, reflect.Map:
-				b, err := json.Marshal(v.Interface())
-				if err != nil {
-					values = append(values, "")
-				} else {
-					values = append(values, string(b))
-				}
-			case reflect.Interface:
-				if v.IsNil() {
-					continue
-				} else {
-					values = append(values, fmt.Sprintf("%v", v.Interface()))
-				}
 			}
 			fields = append(fields, key)
+			values = append(values, val)
 		}
 	}
-
-	return fmt.Sprintf(
-		"INSERT INTO `%s` (%s) VALUES (%s)", statement.TableName, "`"+strings.Join(fields, "`,`")+"`", "'"+strings.Join(values, "','")+"'",
-	), nil
+	fields, values, err := statement.tenantColumns(fields, values)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(statement.pk) > 0 {
+		keys = statement.pk
+	}
+	if err := checkInsertKeys(keys, fields); err != nil {
+		return nil, nil, nil, err
+	}
+	return fields, values, keys, nil
 }
 
-func (statement *Statement) buildMultiInsert(args interface{}) (string, error) {
-	tmp := make([]map[string]string, 0)
+func (statement *Statement) buildMultiInsert(args interface{}) (string, []interface{}, error) {
+	tmp := make([]map[string]interface{}, 0)
+	keys := statement.keyColumns()
 
 	t := reflect.TypeOf(args).Elem().Kind().String()
 	v := reflect.ValueOf(args)
 
 	if t == "ptr" {
+		s := statement.adapter.schemas.schemaOf(reflect.TypeOf(args).Elem())
+		if len(statement.pk) == 0 {
+			keys = s.pkColumns()
+		}
+		now := statement.adapter.now()
 		for l := 0; l < v.Len(); l++ {
-			vv := v.Index(l).Elem()
-			tt := vv.Type()
-			val := ""
-			m := make(map[string]string)
-			for i := 0; i < vv.NumField(); i++ {
-				f := vv.Field(i)
-				setKey := tt.Field(i).Tag.Get("json")
-				setVal := vv.Field(i)
-				if setKey == "" && f.Kind() != reflect.Struct {
-					setKey = FormatUpper(tt.Field(i).Name)
-				}
-				if statement.pk != "" {
-					if setKey == statement.pk {
-						continue
-					}
+			s.stamp(v.Index(l).Elem(), now, true)
+			if err := statement.fillTenant(s, v.Index(l).Elem()); err != nil {
+				return "", nil, err
+			}
+		}
+		fields := make([]*field, 0, len(s.fields))
+		for _, f := range s.fields {
+			if statement.skipOnInsert(f.column) {
+				continue
+			}
+			for l := 0; l < v.Len(); l++ {
+				if fv, ok := f.value(v.Index(l).Elem()); ok && f.insertable(fv) {
+					fields = append(fields, f)
+					break
 				}
-
-				switch f.Kind() {
-				case reflect.Invalid:
-					val = ""
-				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-					val = strconv.FormatInt(setVal.Int(), 10)
-				case reflect.String:
-					val = escapeString(setVal.String())
-				case reflect.Float32:
-					val = strconv.FormatFloat(setVal.Float(), 'f', -1, 32)
-				case reflect.Float64:
-					val = strconv.FormatFloat(setVal.Float(), 'f', -1, 64)
-				case reflect.Struct, reflect.Ptr, reflect.Map:
-					b, err := json.Marshal(setVal.Interface())
-					if err != nil {
-						val = ""
-					} else {
-						val = string(b)
-					}
-				case reflect.Interface:
-					if v.IsNil() {
-						val = ""
-					} else {
-						val = fmt.Sprintf("%v", setVal.Interface())
+			}
+		}
+		for l := 0; l < v.Len(); l++ {
+			vv := v.Index(l).Elem()
+			m := make(map[string]interface{})
+			for _, f := range fields {
+				var val interface{}
+				if fv, ok := f.value(vv); ok {
+					var err error
+					if val, _, err = statement.encodeField(f, fv); err != nil {
+						return "", nil, err
 					}
 				}
-				m[setKey] = val
+				m[f.column] = val
 			}
 			tmp = append(tmp, m)
 		}
@@ -618,41 +949,20 @@ func (statement *Statement) buildMultiInsert(args interface{}) (string, error) {
 		var data []map[string]interface{}
 
 		if data, ok = args.([]map[string]interface{}); !ok {
-			return "", errors.New(PARAMETER_ERROR)
+			return "", nil, errors.New(PARAMETER_ERROR)
 		}
 
+		now := statement.adapter.now()
 		for _, d := range data {
-			if statement.pk != "" {
-				delete(d, statement.pk)
-			}
-			val := ""
-			m := make(map[string]string)
+			d = statement.stampMap(d, now, true)
+			m := make(map[string]interface{})
 			for key, value := range d {
-				v := reflect.ValueOf(value)
-				switch v.Kind() {
-				case reflect.Invalid:
-					val = ""
-				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-					val = strconv.FormatInt(v.Int(), 10)
-				case reflect.String:
-					val = escapeString(v.String())
-				case reflect.Float32:
-					val = strconv.FormatFloat(v.Float(), 'f', -1, 32)
-				case reflect.Float64:
-					val = strconv.FormatFloat(v.Float(), 'f', -1, 64)
-				case reflect.Struct, reflect.Ptr, reflect.Map:
-					b, err := json.Marshal(v.Interface())
-					if err != nil {
-						val = ""
-					} else {
-						val = string(b)
-					}
-				case reflect.Interface:
-					if v.IsNil() {
-						val = ""
-					} else {
-						val = fmt.Sprintf("%v", v.Interface())
-					}
+				if statement.skipOnInsert(key) {
+					continue
+				}
+				val, _, err := statement.encodeValue(key, value)
+				if err != nil {
+					return "", nil, err
 				}
 				m[key] = val
 			}
@@ -660,108 +970,141 @@ func (statement *Statement) buildMultiInsert(args interface{}) (string, error) {
 		}
 	}
 
+	if statement.adapter.tenant != nil {
+		for _, m := range tmp {
+			found := false
+			for key, value := range m {
+				if isTenantColumn(key) {
+					if err := statement.checkTenant(value); err != nil {
+						return "", nil, err
+					}
+					found = true
+				}
+			}
+			if !found {
+				m[tenantColumn] = statement.adapter.tenant.id
+			}
+		}
+	}
+
 	var fields []string
 	for k, _ := range tmp[0] {
 		fields = append(fields, k)
 	}
-
-	values := make([][]string, len(tmp))
-	for _, v := range fields {
-		for i, vv := range tmp {
-			values[i] = append(values[i], vv[v])
+	for _, m := range tmp {
+		columns := make([]string, 0, len(m))
+		for k := range m {
+			columns = append(columns, k)
+		}
+		if err := checkInsertKeys(keys, columns); err != nil {
+			return "", nil, err
 		}
 	}
 
-	vtmp := make([]string, 0)
-	for _, v := range values {
-		vtmp = append(vtmp, fmt.Sprintf("('%s')", strings.Join(v, "','")))
+	params := make([]interface{}, 0, len(fields)*len(tmp))
+	vtmp := make([]string, 0, len(tmp))
+	for _, vv := range tmp {
+		for _, k := range fields {
+			params = append(params, vv[k])
+		}
+		vtmp = append(vtmp, "("+placeholders(len(fields))+")")
 	}
 
 	sql := fmt.Sprintf(
-		"INSERT INTO `%s` (%s) VALUES %s", statement.TableName, "`"+strings.Join(fields, "`,`")+"`", strings.Join(vtmp, ","),
+		"INSERT INTO %s (%s) VALUES %s", statement.table(), "`"+strings.Join(fields, "`,`")+"`", strings.Join(vtmp, ","),
 	)
 
-	return sql, nil
+	return sql, params, nil
 }
 
-func (statement *Statement) buildUpdate(args interface{}) (string, error) {
+func (statement *Statement) buildUpdate(args interface{}) (string, []interface{}, error) {
 	var values []string
+	var params []interface{}
 
 	argsType := reflect.ValueOf(args).Kind().String()
-	/*if !inSlice(argsType, []string{"map", "ptr"}) {
-		return "", errors.New(PARAMETER_ERROR)
-	}*/
-	if !inSlice(argsType, []string{"map"}) {
-		return "", errors.New(PARAMETER_ERROR)
+	if !inSlice(argsType, []string{"map", "ptr"}) {
+		return "", nil, errors.New(PARAMETER_ERROR)
 	}
 
-	cond := statement.formatWhere()
-	if cond == "" {
-		return "", errors.New(WHERE_ERROR)
+	if !statement.filtered() {
+		return "", nil, errors.New(WHERE_ERROR)
+	}
+	cond, condParams, err := statement.prepareWhere()
+	if err != nil {
+		return "", nil, err
 	}
 
 	if argsType == "ptr" {
 		v := reflect.ValueOf(args).Elem()
-		t := v.Type()
-
-		for i := 0; i < v.NumField(); i++ {
-			f := v.Field(i)
-			setKey := t.Field(i).Tag.Get("json")
-			setVal := v.Field(i)
-			if setKey == "" && f.Kind() != reflect.Struct {
-				setKey = FormatUpper(t.Field(i).Name)
+		s := statement.adapter.schemas.schemaOf(v.Type())
+		s.stamp(v, statement.adapter.now(), false)
+		for _, f := range s.fields {
+			if statement.isPk(f.column) || f == s.version {
+				continue
+			}
+			fv, ok := f.value(v)
+			if !ok {
+				continue
 			}
-			if statement.pk != "" {
-				if setKey == statement.pk {
+			if statement.dirty != nil {
+				if !statement.dirty[f.column] && !(f.has(flagAutoUpdate) && f.prefix == "") {
 					continue
 				}
+			} else if !f.updatable(fv) {
+				continue
 			}
-			switch f.Kind() {
-			case reflect.Int, reflect.Int32, reflect.Int64:
-				setParam := fmt.Sprintf("%v = %v", setKey, setVal)
-				values = append(values, setParam)
-			case reflect.String:
-				setParam := fmt.Sprintf("%v = '%v'", setKey, escapeString(setVal.String()))
-				values = append(values, setParam)
-			case reflect.Float32, reflect.Float64:
-				setParam := fmt.Sprintf("%v = '%v'", setKey, strconv.FormatFloat(setVal.Float(), 'f', -1, 64))
-				values = append(values, setParam)
-			case reflect.Ptr:
-				setParam := fmt.Sprintf("%v = '%v'", setKey, escapeString(json_encode(setVal.Interface())))
-				values = append(values, setParam)
+			val, ok, err := statement.encodeField(f, fv)
+			if err != nil {
+				return "", nil, err
+			}
+			if ok {
+				if isTenantColumn(f.column) && statement.adapter.tenant != nil {
+					if err := statement.checkTenant(val); err != nil {
+						return "", nil, err
+					}
+				}
+				values = append(values, fmt.Sprintf("%v = ?", f.column))
+				params = append(params, val)
+			}
+		}
+		if s.version != nil {
+			if fv, ok := s.version.value(v); ok {
+				current := integerOf(fv)
+				values = append(values, fmt.Sprintf("%v = ?", s.version.column))
+				params = append(params, current+1)
+				cond = fmt.Sprintf(" WHERE (%s) AND %s = ?", strings.TrimPrefix(cond, " WHERE "), s.version.column)
+				condParams = append(condParams, current)
 			}
-
 		}
 	} else {
 		insertData, ok := args.(map[string]interface{})
 		if !ok {
-			return "", errors.New(PARAMETER_ERROR)
+			return "", nil, errors.New(PARAMETER_ERROR)
 		}
-		if statement.pk != "" {
-			delete(insertData, statement.pk)
-		}
-
+		insertData = statement.stampMap(insertData, statement.adapter.now(), false)
 		for key, value := range insertData {
-			switch value.(type) {
-			case int, int64, int32:
-				setParam := fmt.Sprintf("%v = %v", key, value)
-				values = append(values, setParam)
-			case string:
-				setParam := fmt.Sprintf("%v = '%v'", key, escapeString(value.(string)))
-				values = append(values, setParam)
-			case float32, float64:
-				setParam := fmt.Sprintf("%v = '%v'", key, strconv.FormatFloat(value.(float64), 'f', -1, 64))
-				values = append(values, setParam)
-			case map[string]interface{}:
-				setParam := fmt.Sprintf("%v = '%v'", key, escapeString(json_encode(value)))
-				values = append(values, setParam)
+			if statement.isPk(key) {
+				continue
+			}
+			val, ok, err := statement.encodeValue(key, value)
+			if err != nil {
+				return "", nil, err
+			}
+			if ok {
+				if isTenantColumn(key) && statement.adapter.tenant != nil {
+					if err := statement.checkTenant(val); err != nil {
+						return "", nil, err
+					}
+				}
+				values = append(values, fmt.Sprintf("%v = ?", key))
+				params = append(params, val)
 			}
 		}
 	}
 
 	return fmt.Sprintf(
-		"UPDATE `%s` SET %s%s", statement.TableName, strings.Join(values, ","), cond,
-	), nil
+		"UPDATE %s SET %s%s", statement.table(), strings.Join(values, ","), cond,
+	), append(params, condParams...), nil
 }
 
 func (statement *Statement) Trace() (file string, line int, function string) {
@@ -0,0 +1,110 @@
+package mysqldb
+
+import (
+	"errors"
+	"log"
+	"reflect"
+	"strings"
+)
+
+const tenantColumn = "tenant_id"
+
+type tenant struct {
+	id interface{}
+}
+
+// ForTenant returns an adapter sharing the connection of adapter whose
+// queries only see the rows of tenant id: reads, counts, updates and deletes
+// are filtered on tenant_id, as are joined tables, and inserts have it set.
+// Raw Query and Exec are refused unless AllowRaw is called.
+// Close and the pool settings of the returned adapter act on the shared
+// pool.
+func (adapter *Adapter) ForTenant(id interface{}) *Adapter {
+	if empty(id) {
+		log.Panicf("ForTenant method: %s", TENANT_ERROR)
+	}
+	scoped := *adapter
+	scoped.tenant = &tenant{id: id}
+	scoped.allowRaw = false
+	return &scoped
+}
+
+// AllowRaw returns a tenant or database adapter that accepts raw Query and
+// Exec, which are neither filtered by tenant nor qualified with the
+// database.
+func (adapter *Adapter) AllowRaw() *Adapter {
+	if adapter.tenant == nil && adapter.database == "" {
+		return adapter
+	}
+	scoped := *adapter
+	scoped.allowRaw = true
+	return &scoped
+}
+
+// checkRaw refuses raw SQL, which is not filtered by tenant nor run in the
+// database of the adapter, on a tenant or database adapter unless AllowRaw
+// was called. Every raw SQL entry point calls it.
+func (model *Model) checkRaw() error {
+	a := model.adapter
+	if (a.tenant == nil && a.database == "") || a.allowRaw {
+		return nil
+	}
+	model.reset()
+	return errors.New(RAW_SQL_ERROR)
+}
+
+// isTenantColumn reports whether column names the tenant column, ignoring
+// case, backquotes and a table qualifier, as MySQL does.
+func isTenantColumn(column string) bool {
+	column = strings.Replace(column, "`", "", -1)
+	if i := strings.LastIndexByte(column, '.'); i >= 0 {
+		column = column[i+1:]
+	}
+	return strings.EqualFold(strings.TrimSpace(column), tenantColumn)
+}
+
+// checkTenant refuses a tenant_id value other than the tenant of the
+// adapter.
+func (statement *Statement) checkTenant(value interface{}) error {
+	if keyString(value) != keyString(statement.adapter.tenant.id) {
+		return errors.New(TENANT_MISMATCH_ERROR)
+	}
+	return nil
+}
+
+// fillTenant sets the tenant field of the struct v when it is zero.
+func (statement *Statement) fillTenant(s *schema, v reflect.Value) error {
+	if statement.adapter.tenant == nil {
+		return nil
+	}
+	f, ok := s.field(tenantColumn)
+	if !ok {
+		return nil
+	}
+	fv := f.settable(v)
+	if !fv.IsZero() {
+		return statement.checkTenant(fv.Interface())
+	}
+	return statement.adapter.assignField(f, fv, statement.adapter.tenant.id)
+}
+
+// tenantColumns adds the tenant column to the columns of an insert, or
+// checks its value when already present.
+func (statement *Statement) tenantColumns(fields []string, values []interface{}) ([]string, []interface{}, error) {
+	if statement.adapter.tenant == nil {
+		return fields, values, nil
+	}
+	found := false
+	for i, column := range fields {
+		if isTenantColumn(column) {
+			if err := statement.checkTenant(values[i]); err != nil {
+				return nil, nil, err
+			}
+			found = true
+		}
+	}
+	if found {
+		return fields, values, nil
+	}
+	return append(fields, tenantColumn), append(values, statement.adapter.tenant.id), nil
+}
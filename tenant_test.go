@@ -0,0 +1,205 @@
+package mysqldb
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+type tenantInvoice struct {
+	Id       int64 `db:"id,pk,autoincr"`
+	TenantId int64 `db:"tenant_id"`
+	Total    int   `db:"total"`
+}
+
+func expectCall(t *testing.T, server *testServer, sql string, args ...driver.Value) {
+	t.Helper()
+	calls := server.sqls()
+	if len(calls) != 1 {
+		t.Fatalf("got %d statements: %v", len(calls), calls)
+	}
+	if calls[0].sql != sql {
+		t.Fatalf("got  %s\nwant %s", calls[0].sql, sql)
+	}
+	if len(args) == 0 {
+		args = []driver.Value{}
+	}
+	got := calls[0].args
+	if got == nil {
+		got = []driver.Value{}
+	}
+	if !reflect.DeepEqual(got, args) {
+		t.Fatalf("got args %#v, want %#v", got, args)
+	}
+}
+
+func TestTenantSelect(t *testing.T) {
+	adapter, server := newTestAdapter()
+	tenant := adapter.ForTenant(7)
+
+	server.push(testResult{columns: []string{"id"}})
+	if _, err := tenant.Table("invoice").Where("id", 3).FetchAll(); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "select * from `invoice` where (id = ?) and tenant_id = ?", int64(3), int64(7))
+
+	server.push(testResult{columns: []string{"id", "tenant_id", "total"}, rows: [][]driver.Value{{int64(1), int64(7), int64(5)}}})
+	var inv tenantInvoice
+	if err := tenant.NewModel().Unscoped().Id(1).First(&inv); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "select id,tenant_id,total from `tenant_invoice` where (id = ?) and tenant_id = ? limit ?", int64(1), int64(7), int64(1))
+}
+
+func TestTenantJoin(t *testing.T) {
+	adapter, server := newTestAdapter()
+	tenant := adapter.ForTenant(7)
+
+	server.push(testResult{columns: []string{"id"}})
+	if _, err := tenant.Table("orders").LeftJoin("items", "A.id = B.order_id").Where("A.id", 3).FetchAll(); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "select * from `orders` as a left join `items` as b on (a.id = b.order_id) and b.tenant_id = ? where (a.id = ?) and a.tenant_id = ?", int64(7), int64(3), int64(7))
+
+}
+
+func TestTenantCount(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(testResult{columns: []string{"aggregate"}, rows: [][]driver.Value{{int64(2)}}})
+	if _, err := adapter.ForTenant(7).Table("invoice").Count(); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "select count(*) as aggregate from `invoice` where tenant_id = ?", int64(7))
+}
+
+func TestTenantUpdate(t *testing.T) {
+	adapter, server := newTestAdapter()
+	tenant := adapter.ForTenant(7)
+
+	server.push(testResult{rowsAffected: 1})
+	if _, err := tenant.Table("invoice").Where("id", 3).Update(map[string]interface{}{"total": 1}); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "UPDATE `invoice` SET total = ? WHERE (id = ?) AND tenant_id = ?", int64(1), int64(3), int64(7))
+
+	for _, key := range []string{"tenant_id", "TENANT_ID", "`tenant_id`", "invoice.Tenant_Id"} {
+		if _, err := tenant.Table("invoice").Where("id", 3).Update(map[string]interface{}{key: 8}); err == nil || err.Error() != TENANT_MISMATCH_ERROR {
+			t.Fatalf("%s: got %v", key, err)
+		}
+	}
+	if _, err := tenant.NewModel().Id(3).Update(&tenantInvoice{Id: 3, TenantId: 8, Total: 1}); err == nil || err.Error() != TENANT_MISMATCH_ERROR {
+		t.Fatalf("got %v", err)
+	}
+	if calls := server.sqls(); len(calls) != 0 {
+		t.Fatalf("unexpected statements: %v", calls)
+	}
+}
+
+func TestTenantDelete(t *testing.T) {
+	adapter, server := newTestAdapter()
+	server.push(testResult{rowsAffected: 1})
+	if _, err := adapter.ForTenant(7).Table("invoice").Unscoped().Where("id", 3).Delete(); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "DELETE FROM `invoice` WHERE (id = ?) AND tenant_id = ?", int64(3), int64(7))
+}
+
+func TestTenantInsert(t *testing.T) {
+	adapter, server := newTestAdapter()
+	tenant := adapter.ForTenant(7)
+
+	server.push(testResult{lastInsertId: 5, rowsAffected: 1})
+	inv := &tenantInvoice{Total: 10}
+	if _, err := tenant.NewModel().Insert(inv); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "INSERT INTO `tenant_invoice` (`tenant_id`,`total`) VALUES (?,?)", int64(7), int64(10))
+	if inv.TenantId != 7 || inv.Id != 5 {
+		t.Fatalf("got %+v", inv)
+	}
+
+	server.push(testResult{lastInsertId: 6, rowsAffected: 1})
+	if _, err := tenant.Table("invoice").Insert(map[string]interface{}{"total": 1}); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "INSERT INTO `invoice` (`total`,`tenant_id`) VALUES (?,?)", int64(1), int64(7))
+
+	if _, err := tenant.NewModel().Insert(&tenantInvoice{TenantId: 9}); err == nil || err.Error() != TENANT_MISMATCH_ERROR {
+		t.Fatalf("got %v", err)
+	}
+	for _, key := range []string{"tenant_id", "TENANT_ID", "`tenant_id`"} {
+		if _, err := tenant.Table("invoice").Insert(map[string]interface{}{key: 9}); err == nil || err.Error() != TENANT_MISMATCH_ERROR {
+			t.Fatalf("%s: got %v", key, err)
+		}
+	}
+	if calls := server.sqls(); len(calls) != 0 {
+		t.Fatalf("unexpected statements: %v", calls)
+	}
+
+	// The tenant column given in another case is kept, not added twice.
+	server.push(testResult{lastInsertId: 7, rowsAffected: 1})
+	if _, err := tenant.Table("invoice").Insert(map[string]interface{}{"TENANT_ID": 7}); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "INSERT INTO `invoice` (`TENANT_ID`) VALUES (?)", int64(7))
+}
+
+func TestTenantMultiInsert(t *testing.T) {
+	adapter, server := newTestAdapter()
+	tenant := adapter.ForTenant(7)
+
+	server.push(testResult{rowsAffected: 2})
+	if _, err := tenant.Table("invoice").MultiInsert([]map[string]interface{}{{"total": 1}, {"total": 2}}); err != nil {
+		t.Fatal(err)
+	}
+	calls := server.sqls()
+	if len(calls) != 1 || !reflect.DeepEqual(calls[0].args, []driver.Value{int64(1), int64(7), int64(2), int64(7)}) && !reflect.DeepEqual(calls[0].args, []driver.Value{int64(7), int64(1), int64(7), int64(2)}) {
+		t.Fatalf("got %v", calls)
+	}
+
+	list := []*tenantInvoice{{Total: 1}, {Total: 2}}
+	server.push(testResult{lastInsertId: 1, rowsAffected: 2})
+	if _, err := tenant.NewModel().MultiInsert(list); err != nil {
+		t.Fatal(err)
+	}
+	if list[0].TenantId != 7 || list[1].TenantId != 7 {
+		t.Fatalf("got %+v %+v", list[0], list[1])
+	}
+	server.sqls()
+
+	if _, err := tenant.NewModel().MultiInsert([]*tenantInvoice{{Total: 1}, {TenantId: 9}}); err == nil || err.Error() != TENANT_MISMATCH_ERROR {
+		t.Fatalf("got %v", err)
+	}
+	for _, key := range []string{"tenant_id", "TENANT_ID", "`tenant_id`"} {
+		if _, err := tenant.Table("invoice").MultiInsert([]map[string]interface{}{{"total": 1}, {key: 9}}); err == nil || err.Error() != TENANT_MISMATCH_ERROR {
+			t.Fatalf("%s: got %v", key, err)
+		}
+	}
+	if calls := server.sqls(); len(calls) != 0 {
+		t.Fatalf("unexpected statements: %v", calls)
+	}
+}
+
+func TestTenantRawSQL(t *testing.T) {
+	adapter, server := newTestAdapter()
+	tenant := adapter.ForTenant(7)
+
+	if _, err := tenant.Query("select * from orders"); err == nil || err.Error() != RAW_SQL_ERROR {
+		t.Fatalf("Query: got %v", err)
+	}
+	if _, err := tenant.Exec("delete from orders"); err == nil || err.Error() != RAW_SQL_ERROR {
+		t.Fatalf("Exec: got %v", err)
+	}
+	if _, err := tenant.NewModel().Query("select * from orders"); err == nil || err.Error() != RAW_SQL_ERROR {
+		t.Fatalf("Model.Query: got %v", err)
+	}
+	if calls := server.sqls(); len(calls) != 0 {
+		t.Fatalf("unexpected statements: %v", calls)
+	}
+
+	server.push(testResult{rowsAffected: 1})
+	if _, err := tenant.AllowRaw().Exec("delete from orders where tenant_id = ?", 7); err != nil {
+		t.Fatal(err)
+	}
+	expectCall(t, server, "delete from orders where tenant_id = ?", int64(7))
+}
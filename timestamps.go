@@ -0,0 +1,112 @@
+package mysqldb
+
+import (
+	"reflect"
+	"time"
+)
+
+// SetClock replaces the time source of autocreate and autoupdate columns,
+// mainly for tests. A nil clock restores time.Now.
+func (adapter *Adapter) SetClock(clock func() time.Time) {
+	adapter.clock = clock
+}
+
+// now returns the current time in the location of the adapter, so string
+// stamps are formatted like the times it writes.
+func (adapter *Adapter) now() time.Time {
+	now := time.Now()
+	if adapter.clock != nil {
+		now = adapter.clock()
+	}
+	if adapter.loc != nil {
+		now = now.In(adapter.loc)
+	}
+	return now
+}
+
+func isStampable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == timeType || t.Kind() == reflect.String || isInteger(t)
+}
+
+// stampValue returns now in the representation of the field: a time, a
+// formatted string, or Unix seconds or milliseconds.
+func (f *field) stampValue(now time.Time) reflect.Value {
+	t := f.typ
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	v := reflect.New(t).Elem()
+	switch {
+	case t == timeType:
+		v.Set(reflect.ValueOf(now))
+	case t.Kind() == reflect.String:
+		v.SetString(now.Format(timeFormat))
+	case f.has(flagMilli):
+		setInteger(v, now.UnixNano()/int64(time.Millisecond))
+	default:
+		setInteger(v, now.Unix())
+	}
+	return v
+}
+
+// deletedValue returns the value marking a row deleted in the softdelete
+// field: now as for timestamp columns, or as a time for other types.
+func (f *field) deletedValue(now time.Time) interface{} {
+	if isStampable(f.typ) {
+		return f.stampValue(now).Interface()
+	}
+	return now
+}
+
+// stamp sets the timestamp fields of the struct v. On insert the autocreate
+// and autoupdate fields left zero are set; on update the autoupdate fields
+// always are.
+func (s *schema) stamp(v reflect.Value, now time.Time, insert bool) {
+	for _, f := range s.stamps {
+		if !insert && !f.has(flagAutoUpdate) {
+			continue
+		}
+		fv := f.settable(v)
+		if insert && !fv.IsZero() {
+			continue
+		}
+		val := f.stampValue(now)
+		if fv.Kind() == reflect.Ptr {
+			p := reflect.New(val.Type())
+			p.Elem().Set(val)
+			val = p
+		}
+		fv.Set(val)
+	}
+}
+
+// stampMap returns a copy of data with the timestamp columns of the bound
+// struct added when missing, or data itself when nothing is added.
+func (statement *Statement) stampMap(data map[string]interface{}, now time.Time, insert bool) map[string]interface{} {
+	if statement.schema == nil || len(statement.schema.stamps) == 0 {
+		return data
+	}
+	var stamped map[string]interface{}
+	for _, f := range statement.schema.stamps {
+		if !insert && !f.has(flagAutoUpdate) {
+			continue
+		}
+		if _, ok := data[f.column]; ok {
+			continue
+		}
+		if stamped == nil {
+			stamped = make(map[string]interface{}, len(data)+len(statement.schema.stamps))
+			for key, value := range data {
+				stamped[key] = value
+			}
+		}
+		stamped[f.column] = f.stampValue(now).Interface()
+	}
+	if stamped == nil {
+		return data
+	}
+	return stamped
+}
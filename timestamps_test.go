@@ -0,0 +1,70 @@
+package mysqldb
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type stampArticle struct {
+	Id        int64  `db:"id,pk"`
+	Title     string `db:"title"`
+	CreatedAt string `db:"created_at,autocreate"`
+	UpdatedAt int64  `db:"updated_at,autoupdate"`
+}
+
+func stampAdapter() (*Adapter, *testServer, time.Time) {
+	adapter, server := newTestAdapter()
+	adapter.loc = time.FixedZone("UTC+8", 8*3600)
+	now := time.Date(2024, 5, 6, 22, 0, 0, 0, time.UTC)
+	adapter.SetClock(func() time.Time { return now })
+	return adapter, server, now
+}
+
+func TestStampStringInAdapterLocation(t *testing.T) {
+	adapter, server, now := stampAdapter()
+
+	a := &stampArticle{Id: 1, Title: "a"}
+	if _, err := adapter.NewModel().Insert(a); err != nil {
+		t.Fatal(err)
+	}
+	if a.CreatedAt != "2024-05-07 06:00:00" {
+		t.Fatalf("created_at: got %q", a.CreatedAt)
+	}
+	expectCall(t, server, "INSERT INTO `stamp_article` (`id`,`title`,`created_at`,`updated_at`) VALUES (?,?,?,?)",
+		int64(1), "a", "2024-05-07 06:00:00", now.Unix())
+}
+
+func TestUpsert(t *testing.T) {
+	adapter, server, now := stampAdapter()
+
+	server.push(testResult{rowsAffected: 2})
+	n, err := adapter.NewModel().Upsert(&stampArticle{Id: 1, Title: "a"})
+	if err != nil || n != 2 {
+		t.Fatalf("got %d, %v", n, err)
+	}
+	expectCall(t, server, "INSERT INTO `stamp_article` (`id`,`title`,`created_at`,`updated_at`) VALUES (?,?,?,?)"+
+		" ON DUPLICATE KEY UPDATE `title` = VALUES(`title`),`updated_at` = VALUES(`updated_at`)",
+		int64(1), "a", "2024-05-07 06:00:00", now.Unix())
+
+	if _, err := adapter.Model(&stampArticle{}).Upsert(map[string]interface{}{"id": 2}); err != nil {
+		t.Fatal(err)
+	}
+	calls := server.sqls()
+	if len(calls) != 1 {
+		t.Fatalf("got %d statements", len(calls))
+	}
+	if !strings.HasSuffix(calls[0].sql, " ON DUPLICATE KEY UPDATE `updated_at` = VALUES(`updated_at`)") {
+		t.Fatalf("got %s", calls[0].sql)
+	}
+}
+
+func TestUpsertRefusedOnTenant(t *testing.T) {
+	adapter, server := newTestAdapter()
+	if _, err := adapter.ForTenant(7).NewModel().Upsert(&stampArticle{Id: 1}); err == nil || err.Error() != TENANT_UPSERT_ERROR {
+		t.Fatalf("got %v", err)
+	}
+	if calls := server.sqls(); len(calls) != 0 {
+		t.Fatalf("unexpected statements: %v", calls)
+	}
+}
@@ -89,43 +89,6 @@ func inSlice(v interface{}, s interface{}) bool {
 	return false
 }
 
-func implode(data interface{}, sep string) string {
-	sv := reflect.Indirect(reflect.ValueOf(data))
-	if sv.Kind() != reflect.Slice {
-		log.Panicln("implode method: second parameter needs a slice")
-		return ""
-	}
-	var tmp []string
-	st := reflect.TypeOf(data).Elem().String()
-	switch st {
-	case "string":
-		for _, vv := range data.([]string) {
-			tmp = append(tmp, singleQuotes(escapeString(vv)))
-		}
-	case "interface {}":
-		for _, vv := range data.([]interface{}) {
-			tmp = append(tmp, singleQuotes(escapeString(formatString(vv))))
-		}
-	case "int":
-		for _, vv := range data.([]int) {
-			tmp = append(tmp, formatString(vv))
-		}
-		return strings.Join(tmp, sep)
-	case "int64":
-		for _, vv := range data.([]int64) {
-			tmp = append(tmp, formatString(vv))
-		}
-	case "float64":
-		for _, vv := range data.([]float64) {
-			tmp = append(tmp, formatString(vv))
-		}
-	default:
-		log.Panicln("implode method: this type is not supported")
-		return ""
-	}
-	return strings.Join(tmp, sep)
-}
-
 func iface2Slice(data interface{}) []interface{} {
 	res := make([]interface{}, 0)
 
@@ -134,30 +97,8 @@ func iface2Slice(data interface{}) []interface{} {
 		return res
 	}
 
-	st := reflect.TypeOf(data).Elem().String()
-	switch st {
-	case "string":
-		for _, vv := range data.([]string) {
-			res = append(res, vv)
-		}
-	case "interface {}":
-		for _, vv := range data.([]interface{}) {
-			res = append(res, vv)
-		}
-	case "int":
-		for _, vv := range data.([]int) {
-			res = append(res, vv)
-		}
-	case "int64":
-		for _, vv := range data.([]int64) {
-			res = append(res, vv)
-		}
-	case "float64":
-		for _, vv := range data.([]float64) {
-			res = append(res, vv)
-		}
-	default:
-		log.Panicln("iface2Slice method: this type is not supported")
+	for i := 0; i < sv.Len(); i++ {
+		res = append(res, sv.Index(i).Interface())
 	}
 
 	return res
@@ -176,6 +117,13 @@ func unique(list *[]string) []string {
 	return r
 }
 
+func placeholders(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strings.Repeat("?,", n-1) + "?"
+}
+
 func isUint8Slice(arg interface{}) bool {
 	switch arg.(type) {
 	case []uint8:
@@ -185,10 +133,6 @@ func isUint8Slice(arg interface{}) bool {
 	}
 }
 
-func singleQuotes(data interface{}) string {
-	return "'" + strings.Trim(escapeString(formatString(data)), " ") + "'"
-}
-
 func convertInt(v interface{}) (int64, error) {
 	switch v.(type) {
 	case int:
@@ -356,31 +300,36 @@ func FormatUpper(str string) string {
 	return strings.Join(name, "_")
 }
 
-func json_encode(i interface{}) string {
-	//json := jsoniter.ConfigCompatibleWithStandardLibrary
-	jsonByte, err := json.Marshal(i)
-	if err != nil {
-		return ""
-	}
-	return string(jsonByte)
+func map2struct(adapter *Adapter, i interface{}, m map[string]interface{}) error {
+	return reflectValue(adapter, i, m)
 }
 
-func map2struct(i interface{}, m map[string]interface{}) error {
-	//json := jsoniter.ConfigCompatibleWithStandardLibrary
-	b, err := json.Marshal(m)
-	if err != nil {
-		return err
+func slice2struct(adapter *Adapter, i interface{}, s []map[string]interface{}) error {
+	sv := reflect.Indirect(reflect.ValueOf(i))
+	if sv.Kind() != reflect.Slice {
+		return errors.New(SLICEPOINTER_ERROR)
 	}
-	return json.Unmarshal(b, i)
-}
 
-func slice2struct(i interface{}, s []map[string]interface{}) error {
-	//json := jsoniter.ConfigCompatibleWithStandardLibrary
-	b, err := json.Marshal(s)
-	if err != nil {
-		return err
+	et := sv.Type().Elem()
+	it := et
+	if it.Kind() == reflect.Ptr {
+		it = it.Elem()
+	}
+
+	list := reflect.MakeSlice(sv.Type(), 0, len(s))
+	for _, m := range s {
+		item := reflect.New(it)
+		if err := reflectValue(adapter, item.Interface(), m); err != nil {
+			return err
+		}
+		if et.Kind() == reflect.Ptr {
+			list = reflect.Append(list, item)
+		} else {
+			list = reflect.Append(list, item.Elem())
+		}
 	}
-	return json.Unmarshal(b, i)
+	sv.Set(list)
+	return nil
 }
 
 func empty(arg interface{}) bool {
@@ -395,93 +344,42 @@ func empty(arg interface{}) bool {
 		}
 		return true
 	case reflect.Ptr:
+		if v.IsNil() {
+			return true
+		}
 		v = v.Elem()
 	}
-	return v.Interface() == reflect.Zero(v.Type()).Interface()
+	return v.IsZero()
 }
 
 func ReflectFields(iface interface{}) []string {
-	fields := make([]string, 0)
-	v := reflect.ValueOf(iface).Elem()
-	t := v.Type()
-	for i := 0; i < v.NumField(); i++ {
-		f := v.Field(i)
-		iKey := t.Field(i).Tag.Get("json")
-		if iKey == "" && f.Kind() != reflect.Struct {
-			iKey = FormatUpper(t.Field(i).Name)
-		}
-		fields = append(fields, iKey)
-	}
-	return fields
+	return defaultSchemas.schemaOf(reflect.TypeOf(iface)).columnNames()
 }
 
-func reflectValue(iface interface{}, source map[string]interface{}) (err error) {
-	v := reflect.ValueOf(iface).Elem()
-	t := v.Type()
-	for i := 0; i < v.NumField(); i++ {
-		f := v.Field(i)
-		iKey := t.Field(i).Tag.Get("json")
-		if iKey == "" && f.Kind() != reflect.Struct {
-			iKey = FormatUpper(t.Field(i).Name)
+func reflectValue(adapter *Adapter, iface interface{}, source map[string]interface{}) error {
+	v := reflect.ValueOf(iface)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New(PARAMETER_ERROR)
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
 		}
-		iVal := source[iKey]
-		/* iVal, ok := source[iKey]
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return errors.New(PARAMETER_ERROR)
+	}
+
+	s := adapter.schemas.schemaOf(v.Type())
+	for key, val := range source {
+		f, ok := s.field(key)
 		if !ok {
 			continue
-		} */
-		switch f.Kind() {
-		case reflect.Bool:
-			val, e := strconv.ParseBool(formatString(iVal))
-			if e != nil {
-				err = e
-			}
-			f.SetBool(val)
-		case reflect.String:
-			f.SetString(formatString(iVal))
-		case reflect.Float32, reflect.Float64:
-			val, e := strconv.ParseFloat(formatString(iVal), 10)
-			if e != nil {
-				err = e
-			}
-			f.SetFloat(val)
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			val, e := strconv.ParseInt(formatString(iVal), 10, 64)
-			if e != nil {
-				val = 0
-				err = e
-			}
-			f.SetInt(val)
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-			val, e := strconv.ParseUint(formatString(iVal), 10, 64)
-			if e != nil {
-				val = 0
-				err = e
-			}
-			f.SetUint(val)
-		case reflect.Struct:
-			if f.CanSet() {
-				if iKey == "" {
-					iVal = source
-				}
-				childFace := reflect.New(f.Type())
-				switch iVal.(type) {
-				case map[string]interface{}:
-					mVal, ok := iVal.(map[string]interface{})
-					if !ok {
-						err = errors.New(iKey + " is not map[string]interface{}")
-					}
-					reflectValue(childFace.Interface(), mVal)
-					f.Set(childFace.Elem())
-				case string:
-					e := json.Unmarshal([]byte(iVal.(string)), childFace.Interface())
-					if e != nil {
-						err = e
-					} else {
-						f.Set(childFace.Elem())
-					}
-				}
-			}
+		}
+		if err := adapter.assignField(f, f.settable(v), val); err != nil {
+			return fmt.Errorf("column %s: %v", key, err)
 		}
 	}
-	return err
+	return nil
 }